@@ -0,0 +1,111 @@
+package wab
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// serviceAliases maps deprecated service names to the service that now
+// implements them. greeter.proto had no package before wab.greeter.v1, so
+// existing clients still call /Greeter/Greet.
+var serviceAliases = map[string]string{
+	"Greeter": "wab.greeter.v1.Greeter",
+}
+
+// registerAliases registers impl a second time under every alias of
+// desc.ServiceName on each registrar. Calls through an alias run the same
+// handlers and interceptors, but log a deprecation warning the first time each
+// method is used.
+func registerAliases(log *zap.SugaredLogger, desc *grpc.ServiceDesc, impl interface{}, registrars ...grpc.ServiceRegistrar) {
+	for alias, target := range serviceAliases {
+		if target != desc.ServiceName {
+			continue
+		}
+
+		log.Infof("Serving deprecated service name %s as an alias for %s", alias, target)
+
+		aliased := aliasServiceDesc(log, desc, alias)
+		for _, registrar := range registrars {
+			registrar.RegisterService(aliased, impl)
+		}
+	}
+}
+
+// aliasedHandler is a Connect or SSE handler that can serve a service under
+// an alias too.
+type aliasedHandler interface {
+	Alias(alias, target string)
+}
+
+// registerHandlerAliases serves every alias on handler, the in-process
+// channel it calls needs them registered with registerAliases.
+func registerHandlerAliases(handler aliasedHandler) {
+	for alias, target := range serviceAliases {
+		handler.Alias(alias, target)
+	}
+}
+
+func aliasServiceDesc(log *zap.SugaredLogger, desc *grpc.ServiceDesc, alias string) *grpc.ServiceDesc {
+	var warned sync.Map
+
+	deprecated := func(method string) {
+		if _, seen := warned.LoadOrStore(method, true); !seen {
+			log.Warnf("Deprecated: /%s/%s was called, clients should switch to /%s/%s", alias, method, desc.ServiceName, method)
+		}
+	}
+
+	aliased := *desc
+	aliased.ServiceName = alias
+	aliased.Methods = make([]grpc.MethodDesc, len(desc.Methods))
+	aliased.Streams = make([]grpc.StreamDesc, len(desc.Streams))
+
+	for idx, method := range desc.Methods {
+		name, handler := method.MethodName, method.Handler
+
+		method.Handler = func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			deprecated(name)
+
+			return handler(srv, ctx, dec, interceptor)
+		}
+
+		aliased.Methods[idx] = method
+	}
+
+	for idx, stream := range desc.Streams {
+		name, handler := stream.StreamName, stream.Handler
+
+		stream.Handler = func(srv interface{}, ss grpc.ServerStream) error {
+			deprecated(name)
+
+			return handler(srv, ss)
+		}
+
+		aliased.Streams[idx] = stream
+	}
+
+	return &aliased
+}
+
+// withoutAliases hides the aliases from reflection so tools only discover
+// the real service names.
+type withoutAliases struct {
+	*grpc.Server
+}
+
+func (s withoutAliases) GetServiceInfo() map[string]grpc.ServiceInfo {
+	info := s.Server.GetServiceInfo()
+
+	for alias := range serviceAliases {
+		delete(info, alias)
+	}
+
+	return info
+}
+
+// servicesWithoutAliases is svr.GetServiceInfo() minus the aliases.
+func servicesWithoutAliases(svr *grpc.Server) map[string]grpc.ServiceInfo {
+	return withoutAliases{svr}.GetServiceInfo()
+}
@@ -0,0 +1,50 @@
+package wab
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// rootURL matches src and href attributes with a root-relative URL, like
+// src="/assets/index-4f1c9a7b.js", but not protocol-relative ones.
+var rootURL = regexp.MustCompile(`(\s(?:src|href)=["'])/([^/])`)
+
+// normalizeBasePath turns the --base-path flag into /tools/wab form, empty
+// when wab is served from the root.
+func normalizeBasePath(basePath string) string {
+	basePath = strings.Trim(basePath, "/")
+	if basePath == "" {
+		return ""
+	}
+
+	return "/" + basePath
+}
+
+// rebaseHTML points a page at base (like /tools/wab/): it gets a <base href>
+// for its relative URLs, and root-relative src and href attributes are moved
+// under base. Pages that already have a <base> are left alone.
+func rebaseHTML(html []byte, base string) []byte {
+	if bytes.Contains(html, []byte("<base ")) {
+		return html
+	}
+
+	if base != "/" {
+		html = rootURL.ReplaceAll(html, []byte("${1}"+base+"${2}"))
+	}
+
+	tag := []byte(`<base href="` + base + `">`)
+
+	idx := bytes.Index(html, []byte("<head>"))
+	if idx < 0 {
+		return append(tag, html...)
+	}
+
+	idx += len("<head>")
+
+	out := make([]byte, 0, len(html)+len(tag))
+	out = append(out, html[:idx]...)
+	out = append(out, tag...)
+
+	return append(out, html[idx:]...)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/fernferret/wab"
+
+	flag "github.com/spf13/pflag"
+)
+
+// runDescriptors implements "wab descriptors", which writes the
+// FileDescriptorSet of the served services for client code generators. It
+// returns the exit code.
+func runDescriptors(args []string) int {
+	flags := flag.NewFlagSet("descriptors", flag.ContinueOnError)
+	output := flags.StringP("output", "o", "-", "file to write the descriptor set to, - for stdout")
+	asJSON := flags.Bool("json", false, "write the set as JSON instead of binary protobuf")
+	options := &wab.Options{}
+	flags.StringSliceVar(&options.ReflectionAllow, "reflection-allow", nil, "only include services matching these patterns (like wab.greeter.*), like the server does")
+	flags.StringSliceVar(&options.ReflectionDeny, "reflection-deny", nil, "leave out services and files matching these patterns (like wab.admin.* or admin/*.proto), like the server does")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s descriptors [-o file.pb] [--json] [--reflection-allow patterns] [--reflection-deny patterns]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	set, err := wab.Descriptors(options)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to build the descriptor set: %v\n", err)
+		return 1
+	}
+
+	var data []byte
+	if *asJSON {
+		data, err = protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(set)
+		data = append(data, '\n')
+	} else {
+		data, err = proto.MarshalOptions{Deterministic: true}.Marshal(set)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode the descriptor set: %v\n", err)
+		return 1
+	}
+
+	if *output == "-" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(*output, data, 0o644)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write the descriptor set: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
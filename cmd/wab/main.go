@@ -3,16 +3,19 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/fernferret/wab"
 	"github.com/fernferret/wab/internal/util"
+	"github.com/fernferret/wab/internal/wabmw"
 	"go.uber.org/zap"
 
 	flag "github.com/spf13/pflag"
 )
 
 func usage() {
-	fmt.Fprintf(os.Stderr, "WAB Version: %s\n\nusage: %s\n", version, os.Args[0])
+	fmt.Fprintf(os.Stderr, "WAB Version: %s\n\nusage: %s [openapi | descriptors | proto diff]\n", version, os.Args[0])
 	flag.PrintDefaults()
 }
 
@@ -22,19 +25,73 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "openapi":
+			os.Exit(runOpenAPI(os.Args[2:]))
+		case "descriptors":
+			os.Exit(runDescriptors(os.Args[2:]))
+		case "proto":
+			os.Exit(runProto(os.Args[2:]))
+		}
+	}
+
 	// HTTP Server options
-	options := &wab.Options{}
+	options := &wab.Options{
+		Version: version,
+	}
 	// Default log level
 	logLevelString := flag.String("level", "info", "log level, can be one of: trace, debug, info, warn, error, fatal, panic")
 
 	flag.StringVarP(&options.Bind, "bind", "b", "127.0.0.1:8080", "set the bind host for the http server")
+	flag.StringVar(&options.TLSCert, "tls-cert", "", "serve HTTPS with this certificate file, needs --tls-key")
+	flag.StringVar(&options.TLSKey, "tls-key", "", "the private key for --tls-cert")
+	flag.StringVar(&options.CSP, "csp", wabmw.DefaultCSP, "the Content-Security-Policy, {nonce} is replaced with a per-request nonce that is also added to the scripts of index.html, empty disables it")
+	flag.StringVar(&options.FrameAncestors, "frame-ancestors", "'none'", "who may embed wab in a frame, as a CSP source list like 'self' https://admin.example.com, empty allows anyone")
+	flag.StringVar(&options.ReferrerPolicy, "referrer-policy", "strict-origin-when-cross-origin", "the Referrer-Policy header, empty disables it")
+	flag.DurationVar(&options.HSTSMaxAge, "hsts-max-age", 365*24*time.Hour, "the max-age of the Strict-Transport-Security header sent over HTTPS, 0 disables it")
+	flag.DurationVar(&options.ReadHeaderTimeout, "read-header-timeout", 10*time.Second, "how long clients get to send the request headers")
+	flag.DurationVar(&options.ReadTimeout, "read-timeout", 30*time.Second, "how long reading a request body may take, streams and websockets are exempt, 0 disables it")
+	flag.DurationVar(&options.WriteTimeout, "write-timeout", time.Minute, "how long writing a response may take, streams and websockets are exempt, 0 disables it")
+	flag.DurationVar(&options.IdleTimeout, "idle-timeout", 2*time.Minute, "how long an idle keep-alive connection stays open")
+	flag.IntVar(&options.MaxHeaderBytes, "max-header-bytes", 1<<20, "the largest request headers (in bytes) the HTTP server reads")
+	flag.Int64Var(&options.MaxBodySize, "max-body-size", 4<<20, "the largest request body (in bytes), bigger ones get a 413, 0 disables the limit")
+	flag.IntVar(&options.MaxConns, "max-conns", 0, "the most HTTP connections open at once, more wait to be accepted, 0 is unlimited")
+	flag.IntVar(&options.MaxConnsPerIP, "max-conns-per-ip", 0, "the most HTTP connections from one address, more are closed right away, trusted proxies are exempt, 0 is unlimited")
+	flag.StringSliceVar(&options.TrustedProxies, "trusted-proxy", nil, "reverse proxies (CIDRs or IPs like 10.0.0.0/8,127.0.0.1) whose Forwarded and X-Forwarded-* headers name the client")
+	flag.BoolVar(&options.ProxyProtocol, "proxy-protocol", false, "expect a PROXY protocol (v1 or v2) header from the --trusted-proxy addresses on the HTTP and gRPC listeners")
+	flag.StringVar(&options.BasePath, "base-path", "", "serve every route under this path (like /tools/wab) when wab sits behind a reverse proxy's sub-path")
 	flag.StringVarP(&options.BindGRPC, "bind-grpc", "g", "127.0.0.1:5050", "set the bind address for the gRPC server")
 	flag.BoolVar(&options.DisableGRPC, "no-grpc", false, "disable the native gRPC binding, grpcweb will still be available")
 	flag.BoolVar(&options.DisableReflection, "no-reflection", false, "disable gRPC reflection, this will prevent gRPCurl from working")
-	flag.BoolVar(&options.DevMode, "dev", false, "if true, CORS headers will be insecure, use if you're splitting the API/Server for now.")
+	flag.StringSliceVar(&options.ReflectionAllow, "reflection-allow", nil, "only reveal services matching these patterns (like wab.greeter.*) through gRPC reflection and the API docs")
+	flag.StringSliceVar(&options.ReflectionDeny, "reflection-deny", nil, "hide services and files matching these patterns (like wab.admin.* or admin/*.proto) from gRPC reflection and the API docs")
+	flag.BoolVar(&options.DevMode, "dev", false, "allow cross-origin requests from the Vite dev server (http://localhost:5173), use if you're splitting the API/Server")
+	flag.StringSliceVar(&options.CORSOrigins, "cors-origin", nil, "origins allowed to make cross-origin requests, exact (https://app.example.com), wildcard subdomain (https://*.example.com) or *")
+	flag.StringSliceVar(&options.CORSAllowHeaders, "cors-allow-header", nil, "extra request headers cross-origin requests may send, grpcweb and Connect headers are always allowed")
+	flag.StringSliceVar(&options.CORSExposeHeaders, "cors-expose-header", nil, "extra response headers cross-origin scripts may read, grpc-status and grpc-message are always exposed")
+	flag.BoolVar(&options.CORSAllowCredentials, "cors-credentials", false, "allow cross-origin requests to send cookies and HTTP auth, can't be used with --cors-origin '*'")
+	flag.DurationVar(&options.CORSMaxAge, "cors-max-age", 10*time.Minute, "how long browsers may cache preflight responses")
 	flag.BoolVar(&options.LogRequests, "log-requests", false, "if true, http requests will be logged, pretty loud")
 	flag.BoolVar(&options.DisableGRPCUI, "no-grpcui", false, "disable the GRPCUI debug endpoint at /grpc-ui/")
+	flag.StringSliceVar(&options.GRPCUIAllow, "grpcui-allow", nil, "only show services matching these patterns in grpcui")
+	flag.StringSliceVar(&options.GRPCUIDeny, "grpcui-deny", nil, "hide services and files matching these patterns from grpcui")
 	flag.BoolVar(&options.DisableGRPCWeb, "no-grpcweb", false, "disable the grpcweb endpoint at /grpc/, this means the embedded Vue app won't work")
+	flag.BoolVar(&options.DisableGRPCWebSockets, "no-grpcweb-websockets", false, "disable the grpcweb websocket transport, browsers will only be able to make unary and server-streaming calls")
+	flag.DurationVar(&options.WebsocketPingInterval, "ws-ping-interval", 30*time.Second, "how often idle grpcweb websockets are pinged, 0 disables pings")
+	flag.Int64Var(&options.WebsocketMaxMessageSize, "ws-max-message-size", 4<<20, "the largest websocket message (in bytes) a grpcweb client may send")
+	flag.BoolVar(&options.DisableREST, "no-rest", false, "disable the REST/JSON endpoints generated from the google.api.http annotations")
+	flag.BoolVar(&options.DisableSSE, "no-sse", false, "disable the Server-Sent Events endpoints for server-streaming methods at /api/sse/")
+	flag.BoolVar(&options.DisableConnect, "no-connect", false, "disable the Connect protocol endpoints at /connect/")
+	flag.StringVar(&options.UIProxy, "ui-proxy", "", "proxy the UI to this Vite dev server (like http://localhost:5173), HMR included, so UI and API share one origin")
+	flag.StringVar(&options.UIDir, "ui-dir", "", "serve the UI from this directory (like ui/dist) instead of the embedded files")
+	flag.StringVar(&options.UIBundle, "ui-bundle", "", "serve the UI from this zip file instead of the embedded files")
+	flag.DurationVar(&options.UIWatchInterval, "ui-watch-interval", time.Second, "how often --ui-dir is checked for changes, 0 disables reloading")
+	flag.StringSliceVar(&options.UIRoutes, "ui-routes", nil, "routes of the Vue app (like /,/about,/users/*), other paths get index.html with a 404 status, by default every path that doesn't look like a file is a route")
+	flag.StringVar(&options.UIAPIBase, "ui-api-base", "", "the address the Vue app sends API calls to (like https://api.example.com), empty uses the address it was loaded from")
+	flag.StringVar(&options.UIAuthMode, "ui-auth-mode", "none", "how the Vue app should authenticate, handed to it as is")
+	flag.BoolVar(&options.DisableAPIDocs, "no-api-docs", false, "disable the HTML API reference at /docs/api")
+	flag.StringVar(&options.ProtoDriftCheck, "proto-drift", wab.ProtoDriftWarn, "what to do when the embedded .proto files or descriptor set don't match the generated code, one of: warn, fail, off")
 	printVersion := flag.Bool("version", false, "print the version and exit")
 	flag.Usage = usage
 	flag.CommandLine.SortFlags = false
@@ -52,7 +109,21 @@ func main() {
 	log := zap.S()
 
 	// Print some info about the server
-	log.Infof("Starting HTTP server: http://%s", options.Bind)
+	if (options.TLSCert == "") != (options.TLSKey == "") {
+		log.Fatal("--tls-cert and --tls-key need to be used together")
+	}
+
+	scheme := "http"
+	if options.TLSCert != "" {
+		scheme = "https"
+	}
+
+	basePath := strings.Trim(options.BasePath, "/")
+	if basePath != "" {
+		basePath += "/"
+	}
+
+	log.Infof("Starting HTTP server: %s://%s/%s", scheme, options.Bind, basePath)
 	server := wab.NewAPIServer(options)
 	server.RunLoop()
 }
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/fernferret/wab"
+
+	flag "github.com/spf13/pflag"
+)
+
+// runOpenAPI implements "wab openapi", which writes the OpenAPI document of
+// the REST/JSON routes for build pipelines. It returns the exit code.
+func runOpenAPI(args []string) int {
+	flags := flag.NewFlagSet("openapi", flag.ContinueOnError)
+	output := flags.StringP("output", "o", "-", "file to write the document to, - for stdout")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s openapi [-o file]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	doc, err := wab.OpenAPI(version)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to build the OpenAPI document: %v\n", err)
+		return 1
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode the OpenAPI document: %v\n", err)
+		return 1
+	}
+
+	data = append(data, '\n')
+
+	if *output == "-" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(*output, data, 0o644)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write the OpenAPI document: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
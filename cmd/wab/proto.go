@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/fernferret/wab/internal/protocheck"
+	wabproto "github.com/fernferret/wab/proto"
+
+	flag "github.com/spf13/pflag"
+)
+
+// runProto implements the "wab proto" subcommands. It returns the exit code.
+func runProto(args []string) int {
+	if len(args) == 0 || args[0] != "diff" {
+		fmt.Fprintf(os.Stderr, "usage: %s proto diff [flags] <old.proto> <new.proto>\n", os.Args[0])
+		return 2
+	}
+
+	return runProtoDiff(args[1:])
+}
+
+// runProtoDiff implements "wab proto diff", which exits with 1 when new.proto
+// breaks clients of old.proto so it can gate merges. Either file can be "-"
+// to read it from stdin, for example:
+//
+//	git show main:proto/greeter.proto | wab proto diff - proto/greeter.proto
+func runProtoDiff(args []string) int {
+	flags := flag.NewFlagSet("proto diff", flag.ContinueOnError)
+	importPaths := flags.StringSliceP("proto_path", "I", nil, "directories to search for imports, the directory of each file is always searched")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s proto diff [flags] <old.proto> <new.proto>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Either file can be - to read it from stdin. Imports that aren't found on disk\n")
+		fmt.Fprintf(os.Stderr, "fall back to the .proto files embedded in wab.\n\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	oldPath, newPath := flags.Arg(0), flags.Arg(1)
+	if oldPath == "-" && newPath == "-" {
+		fmt.Fprintln(os.Stderr, "only one of the files can be read from stdin")
+		return 2
+	}
+
+	older, err := parseProtoFile(oldPath, newPath, *importPaths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	newer, err := parseProtoFile(newPath, oldPath, *importPaths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 2
+	}
+
+	changes := protocheck.Breaking(older, newer)
+	if len(changes) == 0 {
+		fmt.Printf("%s: no breaking changes\n", newer.Path())
+		return 0
+	}
+
+	fmt.Printf("%s: %d breaking change(s)\n", newer.Path(), len(changes))
+
+	for _, change := range changes {
+		fmt.Printf("  %s\n", change)
+	}
+
+	return 1
+}
+
+// parseProtoFile parses path, or stdin when path is "-". Stdin gets the name
+// and directory of other, so imports resolve the same way for both files.
+func parseProtoFile(path, other string, importPaths []string) (protoreflect.FileDescriptor, error) {
+	var content []byte
+
+	source := path
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("reading stdin: %w", err)
+		}
+
+		content, source = data, other
+	}
+
+	name := filepath.Base(source)
+	dirs := append([]string{filepath.Dir(source)}, importPaths...)
+	embedded := wabproto.Sources()
+
+	parser := protoparse.Parser{
+		Accessor: func(filename string) (io.ReadCloser, error) {
+			if filename == name && content != nil {
+				return io.NopCloser(bytes.NewReader(content)), nil
+			}
+
+			for _, dir := range dirs {
+				file, err := os.Open(filepath.Join(dir, filename))
+				if err == nil {
+					return file, nil
+				}
+
+				if !errors.Is(err, fs.ErrNotExist) {
+					return nil, err
+				}
+			}
+
+			if src, ok := embedded[filename]; ok {
+				return io.NopCloser(strings.NewReader(src)), nil
+			}
+
+			return nil, fs.ErrNotExist
+		},
+	}
+
+	parsed, err := parser.ParseFiles(name)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := protodesc.NewFiles(desc.ToFileDescriptorSet(parsed...))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	return files.FindFileByPath(name)
+}
@@ -0,0 +1,29 @@
+package wab
+
+import (
+	"github.com/fernferret/wab/internal/cors"
+)
+
+// devOrigins are where the Vite dev server runs in split mode, --dev allows
+// them so the UI can talk to the API without listing them.
+var devOrigins = []string{
+	"http://localhost:5173",
+	"http://127.0.0.1:5173",
+}
+
+// corsPolicy is the cross-origin policy for the echo routes, grpcweb and its
+// websockets.
+func corsPolicy(options *Options) *cors.Policy {
+	origins := options.CORSOrigins
+	if options.DevMode {
+		origins = append(append([]string{}, origins...), devOrigins...)
+	}
+
+	return &cors.Policy{
+		Origins:          origins,
+		AllowHeaders:     options.CORSAllowHeaders,
+		ExposeHeaders:    options.CORSExposeHeaders,
+		AllowCredentials: options.CORSAllowCredentials,
+		MaxAge:           options.CORSMaxAge,
+	}
+}
@@ -0,0 +1,116 @@
+package wab
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	gpb "github.com/fernferret/wab/gen/greeterpb"
+)
+
+// grpcWebGreet is a grpcweb call of Greet, the body is one length prefixed
+// frame.
+func grpcWebGreet(t *testing.T, origin string) *http.Request {
+	t.Helper()
+
+	data, err := proto.Marshal(&gpb.HelloRequest{Name: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frame := make([]byte, 5, 5+len(data))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(data)))
+
+	req := httptest.NewRequest(http.MethodPost, "/grpc/wab.greeter.v1.Greeter/Greet", bytes.NewReader(append(frame, data...)))
+	req.Header.Set("Content-Type", "application/grpc-web+proto")
+	req.Header.Set("X-Grpc-Web", "1")
+	req.Header.Set("Origin", origin)
+
+	return req
+}
+
+func TestGRPCWebCORS(t *testing.T) {
+	cases := []struct {
+		name        string
+		origins     []string
+		credentials bool
+		origin      string
+		// allowOrigin is the wanted Access-Control-Allow-Origin, empty when
+		// the origin is refused.
+		allowOrigin      string
+		allowCredentials bool
+	}{
+		{
+			name:        "wildcard never allows credentials",
+			origins:     []string{"*"},
+			credentials: true,
+			origin:      "https://evil.example",
+			allowOrigin: "*",
+		},
+		{
+			name:             "listed origin with credentials",
+			origins:          []string{"https://app.example.com"},
+			credentials:      true,
+			origin:           "https://app.example.com",
+			allowOrigin:      "https://app.example.com",
+			allowCredentials: true,
+		},
+		{
+			name:        "unlisted origin",
+			origins:     []string{"https://app.example.com"},
+			credentials: true,
+			origin:      "https://evil.example",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			options := &Options{
+				DisableGRPCUI:        true,
+				CORSOrigins:          tc.origins,
+				CORSAllowCredentials: tc.credentials,
+			}
+
+			s := NewAPIServer(options)
+			s.setupHTTPServer(SetupGRPCHTTPHandler(options))
+
+			for _, req := range []*http.Request{grpcWebGreet(t, tc.origin), preflight(tc.origin)} {
+				resp := httptest.NewRecorder()
+				s.e.ServeHTTP(resp, req)
+
+				if req.Method == http.MethodPost && resp.Code != http.StatusOK {
+					t.Fatalf("the grpcweb call failed with %d: %s", resp.Code, resp.Body)
+				}
+
+				allowOrigin := resp.Header().Get("Access-Control-Allow-Origin")
+				credentials := resp.Header().Get("Access-Control-Allow-Credentials")
+
+				if allowOrigin != tc.allowOrigin {
+					t.Errorf("%s: got Access-Control-Allow-Origin %q, want %q", req.Method, allowOrigin, tc.allowOrigin)
+				}
+
+				want := ""
+				if tc.allowCredentials {
+					want = "true"
+				}
+
+				if credentials != want {
+					t.Errorf("%s: got Access-Control-Allow-Credentials %q, want %q", req.Method, credentials, want)
+				}
+			}
+		})
+	}
+}
+
+func preflight(origin string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, "/grpc/wab.greeter.v1.Greeter/Greet", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web")
+
+	return req
+}
@@ -0,0 +1,41 @@
+package wab
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/fernferret/wab/internal/descriptors"
+	"github.com/fernferret/wab/internal/reflection"
+)
+
+// Descriptors builds the FileDescriptorSet of every service wab serves, with
+// their imports and comments, without starting any servers. The services are
+// filtered by the ReflectionAllow and ReflectionDeny of options, so it's the
+// same set served at /api/descriptors.
+func Descriptors(options *Options) (*descriptorpb.FileDescriptorSet, error) {
+	filter := reflection.Filter{Allow: options.ReflectionAllow, Deny: options.ReflectionDeny}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	baseSvr := grpc.NewServer()
+	greeterVersions(NewGRPCServer()).Register(baseSvr)
+
+	return serviceFileSet(baseSvr, filter)
+}
+
+// serviceFileSet is the FileDescriptorSet of the services registered on svr
+// that filter reveals, aliases and reflection left out.
+func serviceFileSet(svr *grpc.Server, filter reflection.Filter) (*descriptorpb.FileDescriptorSet, error) {
+	resolver, err := descriptors.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := resolver.Services(serviceNames(svr)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return descriptors.FileSet(filter.Services(services)...), nil
+}
@@ -0,0 +1,62 @@
+package wab
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// TestDescriptorsMatchServedSet checks that "wab descriptors" writes the set
+// the server serves at /api/descriptors for the same reflection filter.
+func TestDescriptorsMatchServedSet(t *testing.T) {
+	cases := []struct {
+		name    string
+		options *Options
+	}{
+		{name: "unfiltered", options: &Options{}},
+		{name: "denied", options: &Options{ReflectionDeny: []string{"wab.greeter.v2.*"}}},
+		{name: "allowed", options: &Options{ReflectionAllow: []string{"wab.greeter.v2.*"}}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			set, err := Descriptors(tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tc.options.DisableGRPCUI = true
+			handlers := SetupGRPCHTTPHandler(tc.options)
+
+			resp := httptest.NewRecorder()
+			handlers.Descriptors.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			served := &descriptorpb.FileDescriptorSet{}
+			if err := proto.Unmarshal(resp.Body.Bytes(), served); err != nil {
+				t.Fatal(err)
+			}
+
+			if !proto.Equal(set, served) {
+				t.Errorf("got files %v, want the served %v", fileNames(set), fileNames(served))
+			}
+		})
+	}
+}
+
+func TestDescriptorsBadFilter(t *testing.T) {
+	if _, err := Descriptors(&Options{ReflectionDeny: []string{"wab.["}}); err == nil {
+		t.Errorf("got no error for a malformed pattern")
+	}
+}
+
+func fileNames(set *descriptorpb.FileDescriptorSet) []string {
+	names := make([]string, 0, len(set.File))
+	for _, fd := range set.File {
+		names = append(names, fd.GetName())
+	}
+
+	return names
+}
@@ -0,0 +1,132 @@
+package wab
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/fernferret/wab/internal/protocheck"
+	wabproto "github.com/fernferret/wab/proto"
+)
+
+// Values for Options.ProtoDriftCheck.
+const (
+	ProtoDriftWarn = "warn"
+	ProtoDriftFail = "fail"
+	ProtoDriftOff  = "off"
+)
+
+// modulePrefix is the go_package prefix of the files generated into gen/.
+// Embedded files from other modules (like google/api) are trimmed copies and
+// are never compared.
+const modulePrefix = "github.com/fernferret/wab/"
+
+// checkProtoDrift makes sure the embedded .proto sources and descriptor set
+// still match the generated Go code, which catches editing
+// proto/greeter.proto and forgetting `make proto`.
+func checkProtoDrift(log *zap.SugaredLogger, mode string) {
+	if mode == ProtoDriftOff {
+		return
+	}
+
+	err := protoDrift()
+	if err == nil {
+		log.Debug("Embedded .proto files and descriptor set match the generated code")
+
+		return
+	}
+
+	if mode == ProtoDriftFail {
+		log.Fatalf("Proto drift detected, run `make proto`: %v", err)
+	}
+
+	log.Warnf("Proto drift detected, run `make proto`: %v", err)
+}
+
+// protoDrift parses the embedded sources and returns the first difference
+// from the compiled-in descriptors, then does the same for the embedded
+// descriptor set, which grpcui, reflection and the API docs are served from.
+func protoDrift() error {
+	sources := wabproto.Sources()
+
+	paths := make([]string, 0, len(sources))
+	for path := range sources {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(sources),
+	}
+
+	parsed, err := parser.ParseFiles(paths...)
+	if err != nil {
+		return fmt.Errorf("parsing embedded sources: %w", err)
+	}
+
+	files, err := protodesc.NewFiles(desc.ToFileDescriptorSet(parsed...))
+	if err != nil {
+		return fmt.Errorf("loading embedded sources: %w", err)
+	}
+
+	for _, path := range paths {
+		src, err := files.FindFileByPath(path)
+		if err != nil {
+			return err
+		}
+
+		if err := generatedDrift(src, path); err != nil {
+			return err
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(wabproto.DescriptorSet, set); err != nil {
+		return fmt.Errorf("decoding descriptors.binpb: %w", err)
+	}
+
+	embedded, err := protodesc.NewFiles(set)
+	if err != nil {
+		return fmt.Errorf("loading descriptors.binpb: %w", err)
+	}
+
+	embedded.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		err = generatedDrift(fd, "descriptors.binpb")
+
+		return err == nil
+	})
+
+	return err
+}
+
+// generatedDrift compares want, found in name, with the generated code of
+// its file. Files that aren't generated into gen/ are skipped.
+func generatedDrift(want protoreflect.FileDescriptor, name string) error {
+	opts, _ := want.Options().(*descriptorpb.FileOptions)
+	if !strings.HasPrefix(opts.GetGoPackage(), modulePrefix) {
+		return nil
+	}
+
+	pkg := strings.TrimPrefix(opts.GetGoPackage(), modulePrefix)
+
+	gen, err := protoregistry.GlobalFiles.FindFileByPath(want.Path())
+	if err != nil {
+		return fmt.Errorf("%s has no generated code in %s", want.Path(), pkg)
+	}
+
+	if mismatch := protocheck.Drift(want, gen, name, pkg); mismatch != nil {
+		return mismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package wab
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	wabproto "github.com/fernferret/wab/proto"
+)
+
+func TestProtoDrift(t *testing.T) {
+	if err := protoDrift(); err != nil {
+		t.Errorf("the embedded files don't match the generated code: %v", err)
+	}
+}
+
+// TestStaleDescriptorSet renumbers a field in a copy of the embedded set, the
+// way a forgotten `make proto` would leave it.
+func TestStaleDescriptorSet(t *testing.T) {
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(wabproto.DescriptorSet, set); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range set.File {
+		if file.GetName() != "greeter.proto" {
+			continue
+		}
+
+		for _, message := range file.MessageType {
+			for _, field := range message.Field {
+				if message.GetName() == "MultiHelloRequest" && field.GetName() == "qty" {
+					field.Number = proto.Int32(4)
+				}
+			}
+		}
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fd, err := files.FindFileByPath("greeter.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = generatedDrift(fd, "descriptors.binpb")
+	if err == nil || !strings.Contains(err.Error(), "MultiHelloRequest.qty") || !strings.Contains(err.Error(), "descriptors.binpb") {
+		t.Errorf("got %v, want the renumbered field reported", err)
+	}
+}
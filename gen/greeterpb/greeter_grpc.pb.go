@@ -21,6 +21,9 @@ type GreeterClient interface {
 	// Sends a greeting
 	Greet(ctx context.Context, in *HelloRequest, opts ...grpc.CallOption) (*HelloReply, error)
 	GreetMany(ctx context.Context, in *MultiHelloRequest, opts ...grpc.CallOption) (Greeter_GreetManyClient, error)
+	// Chat style greeting, every request on the stream is answered as soon as
+	// it arrives. Browsers need the grpcweb websocket transport to call this.
+	GreetStream(ctx context.Context, opts ...grpc.CallOption) (Greeter_GreetStreamClient, error)
 }
 
 type greeterClient struct {
@@ -33,7 +36,7 @@ func NewGreeterClient(cc grpc.ClientConnInterface) GreeterClient {
 
 func (c *greeterClient) Greet(ctx context.Context, in *HelloRequest, opts ...grpc.CallOption) (*HelloReply, error) {
 	out := new(HelloReply)
-	err := c.cc.Invoke(ctx, "/Greeter/Greet", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/wab.greeter.v1.Greeter/Greet", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +44,7 @@ func (c *greeterClient) Greet(ctx context.Context, in *HelloRequest, opts ...grp
 }
 
 func (c *greeterClient) GreetMany(ctx context.Context, in *MultiHelloRequest, opts ...grpc.CallOption) (Greeter_GreetManyClient, error) {
-	stream, err := c.cc.NewStream(ctx, &Greeter_ServiceDesc.Streams[0], "/Greeter/GreetMany", opts...)
+	stream, err := c.cc.NewStream(ctx, &Greeter_ServiceDesc.Streams[0], "/wab.greeter.v1.Greeter/GreetMany", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -72,6 +75,37 @@ func (x *greeterGreetManyClient) Recv() (*HelloReply, error) {
 	return m, nil
 }
 
+func (c *greeterClient) GreetStream(ctx context.Context, opts ...grpc.CallOption) (Greeter_GreetStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Greeter_ServiceDesc.Streams[1], "/wab.greeter.v1.Greeter/GreetStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &greeterGreetStreamClient{stream}
+	return x, nil
+}
+
+type Greeter_GreetStreamClient interface {
+	Send(*HelloRequest) error
+	Recv() (*HelloReply, error)
+	grpc.ClientStream
+}
+
+type greeterGreetStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *greeterGreetStreamClient) Send(m *HelloRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *greeterGreetStreamClient) Recv() (*HelloReply, error) {
+	m := new(HelloReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // GreeterServer is the server API for Greeter service.
 // All implementations must embed UnimplementedGreeterServer
 // for forward compatibility
@@ -79,6 +113,9 @@ type GreeterServer interface {
 	// Sends a greeting
 	Greet(context.Context, *HelloRequest) (*HelloReply, error)
 	GreetMany(*MultiHelloRequest, Greeter_GreetManyServer) error
+	// Chat style greeting, every request on the stream is answered as soon as
+	// it arrives. Browsers need the grpcweb websocket transport to call this.
+	GreetStream(Greeter_GreetStreamServer) error
 	mustEmbedUnimplementedGreeterServer()
 }
 
@@ -92,6 +129,9 @@ func (UnimplementedGreeterServer) Greet(context.Context, *HelloRequest) (*HelloR
 func (UnimplementedGreeterServer) GreetMany(*MultiHelloRequest, Greeter_GreetManyServer) error {
 	return status.Errorf(codes.Unimplemented, "method GreetMany not implemented")
 }
+func (UnimplementedGreeterServer) GreetStream(Greeter_GreetStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method GreetStream not implemented")
+}
 func (UnimplementedGreeterServer) mustEmbedUnimplementedGreeterServer() {}
 
 // UnsafeGreeterServer may be embedded to opt out of forward compatibility for this service.
@@ -115,7 +155,7 @@ func _Greeter_Greet_Handler(srv interface{}, ctx context.Context, dec func(inter
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/Greeter/Greet",
+		FullMethod: "/wab.greeter.v1.Greeter/Greet",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(GreeterServer).Greet(ctx, req.(*HelloRequest))
@@ -144,11 +184,37 @@ func (x *greeterGreetManyServer) Send(m *HelloReply) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Greeter_GreetStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GreeterServer).GreetStream(&greeterGreetStreamServer{stream})
+}
+
+type Greeter_GreetStreamServer interface {
+	Send(*HelloReply) error
+	Recv() (*HelloRequest, error)
+	grpc.ServerStream
+}
+
+type greeterGreetStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *greeterGreetStreamServer) Send(m *HelloReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *greeterGreetStreamServer) Recv() (*HelloRequest, error) {
+	m := new(HelloRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Greeter_ServiceDesc is the grpc.ServiceDesc for Greeter service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var Greeter_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "Greeter",
+	ServiceName: "wab.greeter.v1.Greeter",
 	HandlerType: (*GreeterServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
@@ -162,6 +228,12 @@ var Greeter_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Greeter_GreetMany_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GreetStream",
+			Handler:       _Greeter_GreetStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "greeter.proto",
 }
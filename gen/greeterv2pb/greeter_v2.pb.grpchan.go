@@ -0,0 +1,11 @@
+// Code generated by protoc-gen-grpchan. DO NOT EDIT.
+// source: greeter_v2.proto
+
+package greeterv2pb
+
+import "github.com/fullstorydev/grpchan"
+
+// Deprecated: Use RegisterGreeterServer instead.
+func RegisterHandlerGreeter(reg grpchan.ServiceRegistry, srv GreeterServer) {
+	reg.RegisterService(&Greeter_ServiceDesc, srv)
+}
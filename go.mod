@@ -15,8 +15,10 @@ require (
 	github.com/labstack/gommon v0.4.0
 	github.com/spf13/pflag v1.0.5
 	go.uber.org/zap v1.24.0
+	google.golang.org/genproto v0.0.0-20230306155012-7f2fa6fef1f4
 	google.golang.org/grpc v1.55.0
 	google.golang.org/protobuf v1.30.0
+	nhooyr.io/websocket v1.8.6
 )
 
 require (
@@ -39,6 +41,4 @@ require (
 	golang.org/x/sys v0.8.0 // indirect
 	golang.org/x/text v0.9.0 // indirect
 	golang.org/x/time v0.3.0 // indirect
-	google.golang.org/genproto v0.0.0-20230306155012-7f2fa6fef1f4 // indirect
-	nhooyr.io/websocket v1.8.6 // indirect
 )
@@ -0,0 +1,125 @@
+package wab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gpbv2 "github.com/fernferret/wab/gen/greeterv2pb"
+	"github.com/fernferret/wab/internal/apierr"
+)
+
+// GreeterV2Server implements wab.greeter.v2.Greeter. It holds the actual
+// greeting logic, GRPCServer answers v1 calls by adapting them to this.
+type GreeterV2Server struct {
+	gpbv2.UnimplementedGreeterServer
+
+	log *zap.SugaredLogger
+}
+
+func NewGreeterV2Server() *GreeterV2Server {
+	return &GreeterV2Server{
+		log: zap.S().With("version", "v2"),
+	}
+}
+
+// Greet sends a single greeting, "Hello" unless the request picks another one.
+func (gs *GreeterV2Server) Greet(ctx context.Context, req *gpbv2.GreetRequest) (*gpbv2.GreetResponse, error) {
+	gs.log.With("meth", "Greet").Infof("Received: %v", req.GetName())
+
+	return &gpbv2.GreetResponse{Message: greeting(req.GetGreeting(), "Hello", req.GetName())}, nil
+}
+
+// GreetMany sends qty greetings, "Hi" unless the request picks another one,
+// sleeping sleep_seconds between them.
+func (gs *GreeterV2Server) GreetMany(req *gpbv2.GreetManyRequest, svr gpbv2.Greeter_GreetManyServer) error {
+	message := greeting(req.GetGreeting(), "Hi", req.GetName())
+
+	for idx := 0; idx < int(req.Qty); idx++ {
+		reply := gpbv2.GreetResponse{
+			Message: message,
+			Index:   uint32(idx),
+		}
+
+		err := svr.Send(&reply)
+		if err != nil {
+			return err
+		}
+
+		// Don't sleep for the last request
+		if idx < int(req.Qty)-1 {
+			// Sleep for x seconds after each request, can be 0
+			select {
+			case <-time.After(time.Second * time.Duration(req.SleepSeconds)):
+			case <-svr.Context().Done():
+				return gs.cancelled(svr.Context().Err(), time.Second*time.Duration(req.SleepSeconds))
+			}
+		}
+	}
+
+	return nil
+}
+
+// GreetStream answers every greeting on the stream as soon as it arrives, until
+// the client closes its side.
+func (gs *GreeterV2Server) GreetStream(svr gpbv2.Greeter_GreetStreamServer) error {
+	log := gs.log.With("meth", "GreetStream")
+
+	for idx := uint32(0); ; idx++ {
+		req, err := svr.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		log.Infof("Received: %v", req.GetName())
+
+		err = svr.Send(&gpbv2.GreetResponse{
+			Message: greeting(req.GetGreeting(), "Hello", req.GetName()),
+			Index:   idx,
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// cancelled is the error for a GreetMany call whose context ended between two
+// greetings. A deadline asks the client to wait one interval before retrying,
+// the greetings wouldn't come any faster.
+func (gs *GreeterV2Server) cancelled(err error, interval time.Duration) error {
+	st := status.FromContextError(err)
+
+	// If The user cancelled the request log a warning, this isn't an issue
+	// but if there are timeouts happening we might be cancelling requests.
+	if st.Code() == codes.Canceled {
+		gs.log.Warnf("User cancelled request: %s", st.String())
+
+		return apierr.New(codes.Canceled, apierr.ReasonRequestCancelled, "the request was cancelled")
+	}
+
+	gs.log.Error(st.String())
+
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	return apierr.New(st.Code(), apierr.ReasonRequestCancelled, "the request ran out of time", apierr.RetryAfter(interval))
+}
+
+func greeting(greeting, fallback, name string) string {
+	if greeting == "" {
+		greeting = fallback
+	}
+
+	return fmt.Sprintf("%s %s", greeting, name)
+}
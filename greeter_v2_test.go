@@ -0,0 +1,83 @@
+package wab
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gpbv2 "github.com/fernferret/wab/gen/greeterv2pb"
+	"github.com/fernferret/wab/internal/apierr"
+)
+
+// fakeGreetManyStream collects what GreetMany sends, its context is the only
+// other thing GreetMany looks at.
+type fakeGreetManyStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*gpbv2.GreetResponse
+}
+
+func (s *fakeGreetManyStream) Context() context.Context { return s.ctx }
+
+func (s *fakeGreetManyStream) Send(resp *gpbv2.GreetResponse) error {
+	s.sent = append(s.sent, resp)
+
+	return nil
+}
+
+func TestGreetManyCancelled(t *testing.T) {
+	deadline, cancelDeadline := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelDeadline()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		name  string
+		ctx   context.Context
+		code  codes.Code
+		retry time.Duration
+	}{
+		{name: "cancelled", ctx: cancelled, code: codes.Canceled},
+		{name: "deadline", ctx: deadline, code: codes.DeadlineExceeded, retry: 2 * time.Second},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := &fakeGreetManyStream{ctx: tc.ctx}
+			req := &gpbv2.GreetManyRequest{Name: "bob", Qty: 3, SleepSeconds: 2}
+
+			err := NewGreeterV2Server().GreetMany(req, stream)
+
+			st := status.Convert(err)
+			if st.Code() != tc.code {
+				t.Fatalf("got %v, want code %v", err, tc.code)
+			}
+
+			if reason := apierr.Reason(st); reason != apierr.ReasonRequestCancelled {
+				t.Errorf("got reason %s, want %s", reason, apierr.ReasonRequestCancelled)
+			}
+
+			var retry time.Duration
+
+			for _, detail := range st.Details() {
+				if info, ok := detail.(*errdetails.RetryInfo); ok {
+					retry = info.GetRetryDelay().AsDuration()
+				}
+			}
+
+			if retry != tc.retry {
+				t.Errorf("got retry delay %s, want %s", retry, tc.retry)
+			}
+
+			if len(stream.sent) != 1 {
+				t.Errorf("expected one greeting before the stop, got %d", len(stream.sent))
+			}
+		})
+	}
+}
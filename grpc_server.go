@@ -3,23 +3,31 @@ package wab
 import (
 	"context"
 	"fmt"
-	"net"
 	"net/http"
-	"time"
+	"sort"
+	"strings"
 
 	"github.com/fullstorydev/grpchan/inprocgrpc"
-	"github.com/fullstorydev/grpcui"
 	"github.com/fullstorydev/grpcui/standalone"
 	"github.com/improbable-eng/grpc-web/go/grpcweb"
-	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/desc"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/reflection"
-	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 
 	gpb "github.com/fernferret/wab/gen/greeterpb"
-	"github.com/fernferret/wab/proto"
+	gpbv2 "github.com/fernferret/wab/gen/greeterv2pb"
+	"github.com/fernferret/wab/internal/apidocs"
+	"github.com/fernferret/wab/internal/apierr"
+	"github.com/fernferret/wab/internal/connect"
+	"github.com/fernferret/wab/internal/connlimit"
+	"github.com/fernferret/wab/internal/descriptors"
+	"github.com/fernferret/wab/internal/openapi"
+	"github.com/fernferret/wab/internal/reflection"
+	"github.com/fernferret/wab/internal/transcode"
+	"github.com/fernferret/wab/internal/validate"
+	"github.com/fernferret/wab/internal/versions"
 )
 
 // server is used to implement helloworld.GreeterServer.
@@ -36,93 +44,184 @@ type GRPCServer struct {
 	// gpb.UnsafeGreeterServer
 
 	log *zap.SugaredLogger
+
+	// v1 is an adapter, every call is answered by v2.
+	v2 *GreeterV2Server
 }
 
 func NewGRPCServer() *GRPCServer {
 	return &GRPCServer{
 		log: zap.S(),
+		v2:  NewGreeterV2Server(),
 	}
 }
 
+// greeterVersions is every version of the greeter API svr serves.
+func greeterVersions(svr *GRPCServer) *versions.Set {
+	return versions.NewSet("wab.greeter",
+		versions.Version{Name: "v1", Desc: &gpb.Greeter_ServiceDesc, Impl: svr, Deprecated: true},
+		versions.Version{Name: "v2", Desc: &gpbv2.Greeter_ServiceDesc, Impl: svr.v2},
+	)
+}
+
 // SayHello implements helloworld.GreeterServer
 func (gs GRPCServer) Greet(ctx context.Context, in *gpb.HelloRequest) (*gpb.HelloReply, error) {
-	gs.log.With("meth", "Greet").Infof("Received: %v", in.GetName())
+	resp, err := gs.v2.Greet(ctx, &gpbv2.GreetRequest{Name: in.GetName(), Greeting: "Hello"})
+	if err != nil {
+		return nil, err
+	}
 
-	return &gpb.HelloReply{Message: "Hello " + in.GetName()}, nil
+	return &gpb.HelloReply{Message: resp.GetMessage()}, nil
 }
 
 func (gs GRPCServer) GreetMany(req *gpb.MultiHelloRequest, svr gpb.Greeter_GreetManyServer) error {
 	greetReq := req.GetRequest()
 	if greetReq == nil {
-		return status.Error(codes.InvalidArgument, "missing greeting request")
+		return apierr.BadRequest(
+			apierr.ReasonMissingGreeting,
+			"missing greeting request",
+			apierr.Violation("request", "is required"),
+		)
 	}
 
-	for idx := 0; idx < int(req.Qty); idx++ {
-		reply := gpb.HelloReply{
-			Message: fmt.Sprintf("Hi %s (response %d)", req.Request.Name, idx),
-		}
+	return gs.v2.GreetMany(&gpbv2.GreetManyRequest{
+		Name:         greetReq.GetName(),
+		Greeting:     "Hi",
+		Qty:          req.GetQty(),
+		SleepSeconds: req.GetSleepSeconds(),
+	}, greetManyV1{ServerStream: svr, v1: svr})
+}
 
-		err := svr.Send(&reply)
-		if err != nil {
-			return err
-		}
+// GreetStream answers every greeting on the stream as soon as it arrives, until
+// the client closes its side.
+func (gs GRPCServer) GreetStream(svr gpb.Greeter_GreetStreamServer) error {
+	return gs.v2.GreetStream(greetStreamV1{ServerStream: svr, v1: svr})
+}
 
-		// Don't sleep for the last request
-		if idx < int(req.Qty)-1 {
-			// Sleep for x seconds after each request, can be 0
-			select {
-			case <-time.After(time.Second * time.Duration(req.SleepSeconds)):
-			case <-svr.Context().Done():
-				err := svr.Context().Err()
-				status := status.FromContextError(err)
-
-				// If The user cancelled the request log a warning, this isn't an issue
-				// but if there are timeouts happening we might be cancelling requests.
-				if status.Code() == codes.Canceled {
-					gs.log.Warnf("User cancelled request: %s", status.String())
-				} else {
-					gs.log.Error(status.String())
-				}
-
-				return svr.Context().Err()
-			}
-		}
+// greetManyV1 hands v1 GreetMany streams to v2, the v1 replies put the index
+// in the message.
+type greetManyV1 struct {
+	grpc.ServerStream
+	v1 gpb.Greeter_GreetManyServer
+}
+
+func (s greetManyV1) Send(resp *gpbv2.GreetResponse) error {
+	return s.v1.Send(&gpb.HelloReply{
+		Message: fmt.Sprintf("%s (response %d)", resp.GetMessage(), resp.GetIndex()),
+	})
+}
+
+// greetStreamV1 hands v1 GreetStream streams to v2, v1 always says "Hello".
+type greetStreamV1 struct {
+	grpc.ServerStream
+	v1 gpb.Greeter_GreetStreamServer
+}
+
+func (s greetStreamV1) Recv() (*gpbv2.GreetRequest, error) {
+	req, err := s.v1.Recv()
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &gpbv2.GreetRequest{Name: req.GetName(), Greeting: "Hello"}, nil
 }
 
-func (gs *GRPCServer) getGRPCUIHandler(grpcServer *grpc.Server) http.Handler {
-	accessor := protoparse.FileContentsFromMap(map[string]string{
-		"greeter.proto": proto.Greeter,
-	})
-	parser := protoparse.Parser{
-		Accessor: accessor,
+func (s greetStreamV1) Send(resp *gpbv2.GreetResponse) error {
+	return s.v1.Send(&gpb.HelloReply{Message: resp.GetMessage()})
+}
+
+func (gs *GRPCServer) getGRPCUIHandler(grpcServer *grpc.Server, inprocChan *inprocgrpc.Channel, options *Options) http.Handler {
+	filter := reflection.Filter{Allow: options.GRPCUIAllow, Deny: options.GRPCUIDeny}
+	if err := filter.Validate(); err != nil {
+		gs.log.With(zap.Error(err)).Fatalf("Invalid grpcui service filter")
+	}
+
+	files, methods, err := grpcUIDescriptors(grpcServer, filter)
+	if err != nil {
+		gs.log.With(zap.Error(err)).Fatalf("Failed to load services from grpc server")
+	}
+
+	return standalone.Handler(inprocChan, apiTitle, methods, files)
+}
+
+// grpcUIDescriptors converts the descriptors of every service registered on
+// svr that filter reveals (and the files they import) to the protoreflect/desc
+// flavour grpcui uses.
+func grpcUIDescriptors(svr *grpc.Server, filter reflection.Filter) ([]*desc.FileDescriptor, []*desc.MethodDescriptor, error) {
+	resolver, err := descriptors.Load()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	descriptors, err := parser.ParseFiles("greeter.proto")
+	registered, err := resolver.Services(serviceNames(svr)...)
 	if err != nil {
-		gs.log.With(zap.Error(err)).Fatalf("Failed to load greeter files.")
+		return nil, nil, err
 	}
 
-	methods, err := grpcui.AllMethodsForServer(grpcServer)
+	services := filter.Services(registered)
+	set := descriptors.FileSet(services...)
 
+	byName, err := desc.CreateFileDescriptorsFromSet(set)
 	if err != nil {
-		gs.log.With(zap.Error(err)).Fatalf("Failed to load services from grpc server")
+		return nil, nil, err
 	}
 
-	inprocChan := &inprocgrpc.Channel{}
+	files := make([]*desc.FileDescriptor, 0, len(set.File))
+	for _, fdp := range set.File {
+		files = append(files, byName[fdp.GetName()])
+	}
+
+	var methods []*desc.MethodDescriptor
 
-	gpb.RegisterGreeterServer(inprocChan, gs)
+	for _, sd := range services {
+		svc := byName[sd.ParentFile().Path()].FindService(string(sd.FullName()))
+		methods = append(methods, svc.GetMethods()...)
+	}
 
-	return standalone.Handler(inprocChan, "Web Application Bootstrap", methods, descriptors)
+	return files, methods, nil
 }
 
-func (gs *GRPCServer) getGRPCWebHandler(baseSvr *grpc.Server) http.Handler {
-	wrappedGrpc := grpcweb.WrapServer(baseSvr)
+// serviceNames lists the services registered on svr, without the reflection
+// service itself or any aliases.
+func serviceNames(svr *grpc.Server) []string {
+	var names []string
+
+	for name := range servicesWithoutAliases(svr) {
+		if !strings.HasPrefix(name, "grpc.reflection.") {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func (gs *GRPCServer) getGRPCWebHandler(baseSvr *grpc.Server, options *Options) http.Handler {
+	// The websocket transport is what lets browsers make client-streaming and
+	// bidi calls, plain grpcweb over HTTP only does unary and server-streaming.
+	//
+	// Cross-origin calls are left to the CORS middleware of the echo routes.
+	// grpcweb's own CORS handling always allows credentials, so it gets no
+	// origins and only the websockets, which browsers never preflight, are
+	// checked here.
+	policy := corsPolicy(options)
+	wrappedGrpc := grpcweb.WrapServer(baseSvr,
+		grpcweb.WithAllowedRequestHeaders(policy.Headers()),
+		grpcweb.WithWebsockets(!options.DisableGRPCWebSockets),
+		grpcweb.WithWebsocketOriginFunc(func(req *http.Request) bool {
+			if policy.AllowRequest(req) {
+				return true
+			}
+
+			gs.log.Warnf("Rejected grpcweb websocket from origin %q", req.Header.Get("Origin"))
+
+			return false
+		}),
+		grpcweb.WithWebsocketPingInterval(options.WebsocketPingInterval),
+		grpcweb.WithWebsocketsMessageReadLimit(options.WebsocketMaxMessageSize),
+	)
 
 	return http.Handler(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
-		if wrappedGrpc.IsGrpcWebRequest(req) {
+		if wrappedGrpc.IsGrpcWebRequest(req) || wrappedGrpc.IsGrpcWebSocketRequest(req) {
 			wrappedGrpc.ServeHTTP(resp, req)
 
 			return
@@ -133,28 +232,191 @@ func (gs *GRPCServer) getGRPCWebHandler(baseSvr *grpc.Server) http.Handler {
 	}))
 }
 
+// getOpenAPIDocument describes the REST/JSON routes of handler that belong to
+// services the reflection filter reveals.
+func (gs *GRPCServer) getOpenAPIDocument(handler *transcode.Handler, options *Options, filter reflection.Filter) *openapi.Document {
+	var bindings []*transcode.Binding
+
+	for _, binding := range handler.Bindings() {
+		if filter.Reveals(binding.Method.Parent().(protoreflect.ServiceDescriptor)) {
+			bindings = append(bindings, binding)
+		}
+	}
+
+	doc, err := buildOpenAPI(options.Version, bindings)
+	if err != nil {
+		gs.log.With(zap.Error(err)).Fatalf("Failed to build the OpenAPI document")
+	}
+
+	// The paths of the document are relative to the base path.
+	if basePath := normalizeBasePath(options.BasePath); basePath != "" {
+		doc.Servers = []openapi.Server{{URL: basePath}}
+	}
+
+	return doc
+}
+
+// getRESTHandler builds the REST/JSON routes for every registered method that
+// has a google.api.http annotation.
+func (gs *GRPCServer) getRESTHandler(baseSvr *grpc.Server, inprocChan *inprocgrpc.Channel) *transcode.Handler {
+	handler, err := transcode.New(inprocChan, registeredServices(baseSvr)...)
+	if err != nil {
+		gs.log.With(zap.Error(err)).Fatalf("Failed to load REST bindings")
+	}
+
+	return handler
+}
+
+// getSSEHandler builds the Server-Sent Events bridge for every registered
+// server-streaming method.
+func (gs *GRPCServer) getSSEHandler(baseSvr *grpc.Server, inprocChan *inprocgrpc.Channel) *transcode.SSEHandler {
+	handler, err := transcode.NewSSE(inprocChan, registeredServices(baseSvr)...)
+	if err != nil {
+		gs.log.With(zap.Error(err)).Fatalf("Failed to load SSE methods")
+	}
+
+	registerHandlerAliases(handler)
+
+	return handler
+}
+
+// getDescriptorsHandler serves the FileDescriptorSet of every registered
+// service the reflection filter reveals for client code generators.
+func (gs *GRPCServer) getDescriptorsHandler(baseSvr *grpc.Server, filter reflection.Filter) *descriptors.Handler {
+	set, err := serviceFileSet(baseSvr, filter)
+	if err != nil {
+		gs.log.With(zap.Error(err)).Fatalf("Failed to load the descriptor set")
+	}
+
+	handler, err := descriptors.NewHandler(set)
+	if err != nil {
+		gs.log.With(zap.Error(err)).Fatalf("Failed to encode the descriptor set")
+	}
+
+	return handler
+}
+
+// getAPIDocsHandler renders the HTML reference of every registered service
+// the reflection filter reveals.
+func (gs *GRPCServer) getAPIDocsHandler(baseSvr *grpc.Server, options *Options, filter reflection.Filter) http.Handler {
+	resolver, err := descriptors.Load()
+	if err != nil {
+		gs.log.With(zap.Error(err)).Fatalf("Failed to load descriptors for the API reference")
+	}
+
+	services, err := resolver.Services(serviceNames(baseSvr)...)
+	if err != nil {
+		gs.log.With(zap.Error(err)).Fatalf("Failed to load services for the API reference")
+	}
+
+	page, err := apidocs.New(apidocs.Info{Title: apiTitle, Version: options.Version}, filter.Services(services))
+	if err != nil {
+		gs.log.With(zap.Error(err)).Fatalf("Failed to render the API reference")
+	}
+
+	return page
+}
+
+// getConnectHandler serves every registered method over the Connect protocol.
+func (gs *GRPCServer) getConnectHandler(baseSvr *grpc.Server, inprocChan *inprocgrpc.Channel) *connect.Handler {
+	handler, err := connect.New(inprocChan, registeredServices(baseSvr)...)
+	if err != nil {
+		gs.log.With(zap.Error(err)).Fatalf("Failed to load Connect methods")
+	}
+
+	registerHandlerAliases(handler)
+
+	return handler
+}
+
+// registeredServices looks up the descriptor of every service registered on
+// svr in the compiled-in registry.
+func registeredServices(svr *grpc.Server) []protoreflect.ServiceDescriptor {
+	var services []protoreflect.ServiceDescriptor
+
+	for name := range servicesWithoutAliases(svr) {
+		found, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			continue
+		}
+
+		if sd, ok := found.(protoreflect.ServiceDescriptor); ok {
+			services = append(services, sd)
+		}
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].FullName() < services[j].FullName()
+	})
+
+	return services
+}
+
+// registerReflection serves the v1 and v1alpha reflection APIs from the
+// descriptor resolver, so grpcurl sees the proto comments too. Only the
+// services and files the reflection filter reveals can be discovered.
+func registerReflection(log *zap.SugaredLogger, baseSvr *grpc.Server, options *Options) {
+	resolver, err := descriptors.Load()
+	if err != nil {
+		log.With(zap.Error(err)).Fatalf("Failed to load descriptors for reflection")
+	}
+
+	filter := reflectionFilter(log, options)
+
+	reflection.Register(baseSvr, withoutAliases{baseSvr}, resolver, filter)
+}
+
+// reflectionFilter is the --reflection-allow/--reflection-deny filter. It
+// applies to everything that lists the services: reflection, the descriptor
+// set, the API reference and the OpenAPI document.
+func reflectionFilter(log *zap.SugaredLogger, options *Options) reflection.Filter {
+	filter := reflection.Filter{Allow: options.ReflectionAllow, Deny: options.ReflectionDeny}
+	if err := filter.Validate(); err != nil {
+		log.With(zap.Error(err)).Fatalf("Invalid reflection service filter")
+	}
+
+	return filter
+}
+
+// GRPCHandlers holds the HTTP handlers built on top of the gRPC services. A
+// nil handler means the feature was disabled.
+type GRPCHandlers struct {
+	GRPCWeb http.Handler
+	GRPCUI  http.Handler
+	REST    *transcode.Handler
+	OpenAPI *openapi.Document
+	SSE     *transcode.SSEHandler
+	Connect *connect.Handler
+
+	// Versions is every versioned API, served at /api/v1/versions.
+	Versions []*versions.Set
+	// Descriptors serves the FileDescriptorSet at /api/descriptors.
+	Descriptors *descriptors.Handler
+	APIDocs     http.Handler
+}
+
 // SetupGRPCHTTPHandler builds an in-memory GRPC handler, but does not start a
 // server.
-func SetupGRPCHTTPHandler(enableGRPCWeb, enableGRPCUI bool) (http.Handler, http.Handler) {
-	_, grpcWebHandler, uiHandler := setupGRPCAndHandlers(enableGRPCWeb, enableGRPCUI)
+func SetupGRPCHTTPHandler(options *Options) *GRPCHandlers {
+	_, handlers := setupGRPCAndHandlers(options)
 
-	return grpcWebHandler, uiHandler
+	return handlers
 }
 
-func ServeGRPC(log *zap.SugaredLogger, bind string, enableReflection, enableGRPCWeb, enableGRPCUI bool) (http.Handler, http.Handler) {
-	lis, err := net.Listen("tcp", bind)
+func ServeGRPC(log *zap.SugaredLogger, options *Options) *GRPCHandlers {
+	lis, err := listen(options.BindGRPC, options, connlimit.Limits{})
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
 	log.Infof("server listening at %v", lis.Addr())
 
-	baseSvr, grpcWebHandler, uiHandler := setupGRPCAndHandlers(enableGRPCWeb, enableGRPCUI)
+	baseSvr, handlers := setupGRPCAndHandlers(options)
 
 	// Enable the gRPC reflection:
 	// https://github.com/grpc/grpc-go/blob/master/Documentation/server-reflection-tutorial.md
-	if enableReflection {
-		reflection.Register(baseSvr)
+	if !options.DisableReflection {
+		registerReflection(log, baseSvr, options)
 	}
 
 	go func() {
@@ -163,10 +425,10 @@ func ServeGRPC(log *zap.SugaredLogger, bind string, enableReflection, enableGRPC
 		}
 	}()
 
-	return grpcWebHandler, uiHandler
+	return handlers
 }
 
-func setupGRPCAndHandlers(enableGRPCWeb, enableGRPCUI bool) (*grpc.Server, http.Handler, http.Handler) {
+func setupGRPCAndHandlers(options *Options) (*grpc.Server, *GRPCHandlers) {
 	// Build a new GRPC Server that will handle the requests. This server is
 	// provided by the grpc libraries and will serve as the endpoint where we will
 	// "register" our methods with.
@@ -174,28 +436,77 @@ func setupGRPCAndHandlers(enableGRPCWeb, enableGRPCUI bool) (*grpc.Server, http.
 	// This is very similar to creating a new HTTPServer in go and then adding
 	// handlers to it. A struct is used so method correctness can be enforced at
 	// compile time. See the bottom of the file for the extra compiler check!
-	baseSvr := grpc.NewServer()
+	//
+	// Every request is checked against the (wab.validate.rules) constraints in
+	// the proto files before it reaches our implementation.
+	baseSvr := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(validate.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(validate.StreamServerInterceptor()),
+	)
 
 	// Now build an implementation of our methods. This is the struct defined our
 	// code.
 	svr := NewGRPCServer()
 
-	gpb.RegisterGreeterServer(baseSvr, svr)
+	// Every version of the greeter API is its own service, v1 is answered by
+	// the v2 implementation. Calls are counted per version to show when v1 can
+	// be retired.
+	greeters := greeterVersions(svr)
+
+	// The in-process channel lets the HTTP side (grpcui, REST, SSE and Connect)
+	// call our methods without a network hop. It skips the grpc.Server
+	// entirely, so it needs its own copy of the validation interceptors.
+	inprocChan := (&inprocgrpc.Channel{}).
+		WithServerUnaryInterceptor(validate.UnaryServerInterceptor()).
+		WithServerStreamInterceptor(validate.StreamServerInterceptor())
+
+	greeters.Register(baseSvr, inprocChan)
+
+	// Clients from before the wab.greeter.v1 package keep working through the
+	// old unqualified name, over gRPC, grpcweb, Connect and SSE. Their calls
+	// count as v1.
+	v1Desc, v1Impl := greeters.ServiceDesc(gpb.Greeter_ServiceDesc.ServiceName)
+	registerAliases(svr.log, v1Desc, v1Impl, baseSvr, inprocChan)
+
+	filter := reflectionFilter(svr.log, options)
+
+	handlers := &GRPCHandlers{
+		Versions:    []*versions.Set{greeters},
+		Descriptors: svr.getDescriptorsHandler(baseSvr, filter),
+	}
+
+	if !options.DisableGRPCWeb {
+		handlers.GRPCWeb = svr.getGRPCWebHandler(baseSvr, options)
+	}
+
+	if !options.DisableGRPCUI {
+		handlers.GRPCUI = svr.getGRPCUIHandler(baseSvr, inprocChan, options)
+	}
+
+	if !options.DisableREST {
+		handlers.REST = svr.getRESTHandler(baseSvr, inprocChan)
+		handlers.OpenAPI = svr.getOpenAPIDocument(handlers.REST, options, filter)
+	}
 
-	var uiHandler, grpcWebHandler http.Handler
+	if !options.DisableSSE {
+		handlers.SSE = svr.getSSEHandler(baseSvr, inprocChan)
+	}
 
-	if enableGRPCWeb {
-		grpcWebHandler = svr.getGRPCWebHandler(baseSvr)
+	if !options.DisableAPIDocs {
+		handlers.APIDocs = svr.getAPIDocsHandler(baseSvr, options, filter)
 	}
 
-	if enableGRPCUI {
-		uiHandler = svr.getGRPCUIHandler(baseSvr)
+	if !options.DisableConnect {
+		handlers.Connect = svr.getConnectHandler(baseSvr, inprocChan)
 	}
 
-	return baseSvr, grpcWebHandler, uiHandler
+	return baseSvr, handlers
 }
 
 // This check makes sure we're implementing the server correctly and can catch
 // incorrect methods like pointer receivers. It isn't actually used and is
 // thrown away after compile time.
-var _ gpb.GreeterServer = GRPCServer{}
+var (
+	_ gpb.GreeterServer   = GRPCServer{}
+	_ gpbv2.GreeterServer = &GreeterV2Server{}
+)
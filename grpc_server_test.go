@@ -0,0 +1,240 @@
+package wab
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/protobuf/proto"
+	"nhooyr.io/websocket"
+
+	gpb "github.com/fernferret/wab/gen/greeterpb"
+)
+
+// wsFrameReader reassembles the grpcweb frames a server sends over a
+// websocket. The server may split a single frame across several websocket
+// messages (and the other way around), so everything is buffered first.
+type wsFrameReader struct {
+	conn *websocket.Conn
+	buf  bytes.Buffer
+}
+
+// next returns the flags and payload of the next grpcweb frame.
+func (r *wsFrameReader) next(ctx context.Context) (byte, []byte, error) {
+	for r.buf.Len() < 5 || r.buf.Len() < 5+int(binary.BigEndian.Uint32(r.buf.Bytes()[1:5])) {
+		_, data, err := r.conn.Read(ctx)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		r.buf.Write(data)
+	}
+
+	header := r.buf.Next(5)
+	payload := make([]byte, binary.BigEndian.Uint32(header[1:5]))
+	copy(payload, r.buf.Next(len(payload)))
+
+	return header[0], payload, nil
+}
+
+// nextMessage skips header frames and decodes the next data frame into msg. If
+// the trailers arrive instead, they're returned.
+func (r *wsFrameReader) nextMessage(ctx context.Context, msg proto.Message) (textproto.MIMEHeader, error) {
+	for {
+		flags, payload, err := r.next(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if flags&0x80 == 0 {
+			return nil, proto.Unmarshal(payload, msg)
+		}
+
+		headers, err := textproto.NewReader(bufio.NewReader(bytes.NewReader(append(payload, '\r', '\n')))).ReadMIMEHeader()
+		if err != nil {
+			return nil, err
+		}
+
+		if headers.Get("grpc-status") != "" {
+			return headers, nil
+		}
+	}
+}
+
+func sendWSMessage(ctx context.Context, conn *websocket.Conn, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	// The first byte is the grpcweb websocket control byte (0 is data),
+	// followed by a regular length prefixed gRPC frame.
+	frame := make([]byte, 6, 6+len(data))
+	binary.BigEndian.PutUint32(frame[2:6], uint32(len(data)))
+
+	return conn.Write(ctx, websocket.MessageBinary, append(frame, data...))
+}
+
+func TestGreetStreamOverGRPCWebWebsocket(t *testing.T) {
+	handlers := SetupGRPCHTTPHandler(&Options{
+		DisableGRPCUI:           true,
+		DisableREST:             true,
+		DisableSSE:              true,
+		WebsocketMaxMessageSize: 4 << 20,
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/grpc/", http.StripPrefix("/grpc", handlers.GRPCWeb))
+
+	svr := httptest.NewServer(mux)
+	defer svr.Close()
+
+	// The unqualified name is the deprecated alias from before greeter.proto
+	// had a package, it has to keep working for old clients.
+	for _, service := range []string{"wab.greeter.v1.Greeter", "Greeter"} {
+		t.Run(service, func(t *testing.T) {
+			testGreetStream(t, svr.URL, service)
+		})
+	}
+}
+
+func testGreetStream(t *testing.T, serverURL, service string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	wsURL := "ws" + strings.TrimPrefix(serverURL, "http") + "/grpc/" + service + "/GreetStream"
+
+	conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+		Subprotocols: []string{"grpc-websockets"},
+		HTTPHeader:   http.Header{"Origin": []string{serverURL}},
+	})
+	if err != nil {
+		t.Fatalf("failed to dial the grpcweb websocket: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	// The first message carries the request headers.
+	headers := "content-type: application/grpc-web+proto\r\nx-grpc-web: 1\r\n"
+	if err := conn.Write(ctx, websocket.MessageBinary, []byte(headers)); err != nil {
+		t.Fatalf("failed to send headers: %v", err)
+	}
+
+	reader := &wsFrameReader{conn: conn}
+
+	// Chat style: every greeting is answered before the next one is sent.
+	for _, name := range []string{"alice", "bob"} {
+		if err := sendWSMessage(ctx, conn, &gpb.HelloRequest{Name: name}); err != nil {
+			t.Fatalf("failed to send %q: %v", name, err)
+		}
+
+		reply := &gpb.HelloReply{}
+
+		trailers, err := reader.nextMessage(ctx, reply)
+		if err != nil {
+			t.Fatalf("failed to read the reply to %q: %v", name, err)
+		}
+
+		if trailers != nil {
+			t.Fatalf("stream ended early with status %s: %s", trailers.Get("grpc-status"), trailers.Get("grpc-message"))
+		}
+
+		if want := fmt.Sprintf("Hello %s", name); reply.GetMessage() != want {
+			t.Errorf("got reply %q, want %q", reply.GetMessage(), want)
+		}
+	}
+
+	// A single byte of 1 closes the client side of the stream.
+	if err := conn.Write(ctx, websocket.MessageBinary, []byte{1}); err != nil {
+		t.Fatalf("failed to close the send side: %v", err)
+	}
+
+	trailers, err := reader.nextMessage(ctx, &gpb.HelloReply{})
+	if err != nil && err != io.EOF {
+		t.Fatalf("failed to read trailers: %v", err)
+	}
+
+	if trailers == nil || trailers.Get("grpc-status") != "0" {
+		t.Errorf("expected an OK status, got trailers %v", trailers)
+	}
+}
+
+func TestLegacyGreeterAliasOverHTTP(t *testing.T) {
+	handlers := SetupGRPCHTTPHandler(&Options{
+		DisableGRPCUI: true,
+		DisableREST:   true,
+	})
+
+	e := echo.New()
+	handlers.Connect.Register(e, "/connect")
+	handlers.SSE.Register(e, "/api/sse")
+
+	svr := httptest.NewServer(e)
+	defer svr.Close()
+
+	// The unqualified name is the deprecated alias from before greeter.proto
+	// had a package, it has to keep working for old clients.
+	for _, service := range []string{"wab.greeter.v1.Greeter", "Greeter"} {
+		t.Run("connect "+service, func(t *testing.T) {
+			resp, err := http.Post(svr.URL+"/connect/"+service+"/Greet", "application/json", strings.NewReader(`{"name":"alice"}`))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "Hello alice") {
+				t.Errorf("got %d %s, want a greeting", resp.StatusCode, body)
+			}
+		})
+
+		t.Run("sse "+service, func(t *testing.T) {
+			resp, err := http.Get(svr.URL + "/api/sse/" + service + "/GreetMany?request.name=bob&qty=1")
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "Hi bob") {
+				t.Errorf("got %d %s, want a greeting", resp.StatusCode, body)
+			}
+		})
+	}
+}
+
+func TestReflectionFilterHidesServicesFromDocs(t *testing.T) {
+	handlers := SetupGRPCHTTPHandler(&Options{
+		DisableGRPCUI:  true,
+		ReflectionDeny: []string{"wab.greeter.v2.*"},
+	})
+
+	for path := range handlers.OpenAPI.Paths {
+		if strings.HasPrefix(path, "/api/v2/") {
+			t.Errorf("the OpenAPI document lists the hidden %s", path)
+		}
+	}
+
+	if _, ok := handlers.OpenAPI.Paths["/api/v1/greet"]; !ok {
+		t.Errorf("the OpenAPI document lost /api/v1/greet")
+	}
+
+	for _, handler := range []http.Handler{handlers.Descriptors, handlers.APIDocs} {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/?format=json", nil))
+
+		body := resp.Body.String()
+		if strings.Contains(body, "wab.greeter.v2") || !strings.Contains(body, "wab.greeter.v1") {
+			t.Errorf("expected only wab.greeter.v1 in %T", handler)
+		}
+	}
+}
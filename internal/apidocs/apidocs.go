@@ -0,0 +1,346 @@
+// Package apidocs renders a browsable HTML reference of a set of gRPC
+// services: their methods, the messages and enums those use, the leading proto
+// comments of all of them and an example of every message in the protojson
+// form the REST, SSE and Connect endpoints take. The page is built once from
+// the descriptors and served as is, it doesn't depend on the embedded UI.
+package apidocs
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/fernferret/wab/gen/validatepb"
+	"github.com/fernferret/wab/internal/validate"
+)
+
+//go:embed page.html
+var pageTemplate string
+
+var tmpl = template.Must(template.New("page").Parse(pageTemplate))
+
+// Info is shown at the top of the page.
+type Info struct {
+	Title   string
+	Version string
+}
+
+// Page is the rendered reference.
+type Page struct {
+	html []byte
+}
+
+// New renders the reference of services. The descriptors need their source
+// info for the comments to show up, like the ones from package descriptors.
+func New(info Info, services []protoreflect.ServiceDescriptor) (*Page, error) {
+	b := &builder{
+		messages: map[protoreflect.FullName]*message{},
+		enums:    map[protoreflect.FullName]*enum{},
+	}
+
+	data := page{Info: info}
+	for _, sd := range services {
+		data.Services = append(data.Services, b.service(sd))
+	}
+
+	for _, msg := range b.messages {
+		data.Messages = append(data.Messages, msg)
+	}
+
+	sort.Slice(data.Messages, func(i, j int) bool {
+		return data.Messages[i].Name < data.Messages[j].Name
+	})
+
+	for _, enum := range b.enums {
+		data.Enums = append(data.Enums, enum)
+	}
+
+	sort.Slice(data.Enums, func(i, j int) bool {
+		return data.Enums[i].Name < data.Enums[j].Name
+	})
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("rendering API reference: %w", err)
+	}
+
+	return &Page{html: buf.Bytes()}, nil
+}
+
+func (p *Page) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	resp.Header().Set("Content-Type", "text/html; charset=utf-8")
+	resp.WriteHeader(http.StatusOK)
+
+	if req.Method != http.MethodHead {
+		_, _ = resp.Write(p.html)
+	}
+}
+
+type page struct {
+	Info     Info
+	Services []*service
+	Messages []*message
+	Enums    []*enum
+}
+
+type service struct {
+	Name    string
+	Comment string
+	Methods []*method
+}
+
+type method struct {
+	Name    string
+	Path    string
+	Kind    string
+	Comment string
+	Input   typeRef
+	Output  typeRef
+}
+
+// typeRef is how a field or method refers to a type. Anchor is empty for
+// scalars and types that aren't documented on the page, like the well-known
+// types.
+type typeRef struct {
+	Name   string
+	Anchor string
+}
+
+type message struct {
+	Name    string
+	Comment string
+	Fields  []*field
+	Example string
+}
+
+type field struct {
+	Name     string
+	JSONName string
+	Number   int
+	Label    string
+	Type     typeRef
+	// Value is the map value type, Type is the key.
+	Value   *typeRef
+	Rules   string
+	Comment string
+}
+
+type enum struct {
+	Name    string
+	Comment string
+	Values  []*enumValue
+}
+
+type enumValue struct {
+	Name    string
+	Number  int
+	Comment string
+}
+
+// builder collects every message and enum reachable from the services.
+type builder struct {
+	messages map[protoreflect.FullName]*message
+	enums    map[protoreflect.FullName]*enum
+}
+
+func (b *builder) service(sd protoreflect.ServiceDescriptor) *service {
+	svc := &service{
+		Name:    string(sd.FullName()),
+		Comment: comment(sd),
+	}
+
+	methods := sd.Methods()
+	for idx := 0; idx < methods.Len(); idx++ {
+		md := methods.Get(idx)
+
+		svc.Methods = append(svc.Methods, &method{
+			Name:    string(md.Name()),
+			Path:    fmt.Sprintf("/%s/%s", sd.FullName(), md.Name()),
+			Kind:    kind(md),
+			Comment: comment(md),
+			Input:   b.messageRef(md.Input()),
+			Output:  b.messageRef(md.Output()),
+		})
+	}
+
+	return svc
+}
+
+func kind(md protoreflect.MethodDescriptor) string {
+	switch {
+	case md.IsStreamingClient() && md.IsStreamingServer():
+		return "bidi streaming"
+	case md.IsStreamingClient():
+		return "client streaming"
+	case md.IsStreamingServer():
+		return "server streaming"
+	default:
+		return "unary"
+	}
+}
+
+func (b *builder) messageRef(md protoreflect.MessageDescriptor) typeRef {
+	ref := typeRef{Name: string(md.FullName())}
+	if wellKnown(md.FullName()) {
+		return ref
+	}
+
+	ref.Anchor = anchor(md.FullName())
+
+	if _, ok := b.messages[md.FullName()]; ok {
+		return ref
+	}
+
+	msg := &message{
+		Name:    string(md.FullName()),
+		Comment: comment(md),
+	}
+
+	// Registered before the fields so recursive messages stop here.
+	b.messages[md.FullName()] = msg
+
+	fields := md.Fields()
+	for idx := 0; idx < fields.Len(); idx++ {
+		msg.Fields = append(msg.Fields, b.field(fields.Get(idx)))
+	}
+
+	msg.Example = example(md)
+
+	return ref
+}
+
+func (b *builder) enumRef(ed protoreflect.EnumDescriptor) typeRef {
+	ref := typeRef{Name: string(ed.FullName())}
+	if wellKnown(ed.FullName()) {
+		return ref
+	}
+
+	ref.Anchor = anchor(ed.FullName())
+
+	if _, ok := b.enums[ed.FullName()]; ok {
+		return ref
+	}
+
+	enum := &enum{
+		Name:    string(ed.FullName()),
+		Comment: comment(ed),
+	}
+
+	values := ed.Values()
+	for idx := 0; idx < values.Len(); idx++ {
+		vd := values.Get(idx)
+
+		enum.Values = append(enum.Values, &enumValue{
+			Name:    string(vd.Name()),
+			Number:  int(vd.Number()),
+			Comment: comment(vd),
+		})
+	}
+
+	b.enums[ed.FullName()] = enum
+
+	return ref
+}
+
+func (b *builder) field(fd protoreflect.FieldDescriptor) *field {
+	f := &field{
+		Name:     string(fd.Name()),
+		JSONName: fd.JSONName(),
+		Number:   int(fd.Number()),
+		Rules:    rules(validate.Rules(fd)),
+		Comment:  comment(fd),
+	}
+
+	switch {
+	case fd.IsMap():
+		value := b.typeRef(fd.MapValue())
+		f.Label, f.Type, f.Value = "map", b.typeRef(fd.MapKey()), &value
+	case fd.IsList():
+		f.Label, f.Type = "repeated", b.typeRef(fd)
+	default:
+		if fd.HasOptionalKeyword() {
+			f.Label = "optional"
+		}
+
+		f.Type = b.typeRef(fd)
+	}
+
+	return f
+}
+
+func (b *builder) typeRef(fd protoreflect.FieldDescriptor) typeRef {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return b.messageRef(fd.Message())
+	case protoreflect.EnumKind:
+		return b.enumRef(fd.Enum())
+	default:
+		return typeRef{Name: fd.Kind().String()}
+	}
+}
+
+// rules describes the (wab.validate.rules) of a field.
+func rules(r *validatepb.FieldRules) string {
+	if r == nil {
+		return ""
+	}
+
+	var parts []string
+	if r.GetRequired() {
+		parts = append(parts, "required")
+	}
+
+	if r.Min != nil {
+		parts = append(parts, fmt.Sprintf("min %d", r.GetMin()))
+	}
+
+	if r.Max != nil {
+		parts = append(parts, fmt.Sprintf("max %d", r.GetMax()))
+	}
+
+	if r.MinLen != nil {
+		parts = append(parts, fmt.Sprintf("min length %d", r.GetMinLen()))
+	}
+
+	if r.MaxLen != nil {
+		parts = append(parts, fmt.Sprintf("max length %d", r.GetMaxLen()))
+	}
+
+	if r.GetPattern() != "" {
+		parts = append(parts, fmt.Sprintf("matches %s", r.GetPattern()))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// comment returns the leading comment of d. Hard wrapped lines are joined,
+// blank lines still separate paragraphs.
+func comment(d protoreflect.Descriptor) string {
+	loc := d.ParentFile().SourceLocations().ByDescriptor(d)
+
+	lines := strings.Split(strings.TrimSpace(loc.LeadingComments), "\n")
+	for idx, line := range lines {
+		lines[idx] = strings.TrimSpace(line)
+	}
+
+	paragraphs := strings.Split(strings.Join(lines, "\n"), "\n\n")
+	for idx, paragraph := range paragraphs {
+		paragraphs[idx] = strings.ReplaceAll(paragraph, "\n", " ")
+	}
+
+	return strings.Join(paragraphs, "\n\n")
+}
+
+func anchor(name protoreflect.FullName) string {
+	return "type-" + string(name)
+}
+
+func wellKnown(name protoreflect.FullName) bool {
+	return strings.HasPrefix(string(name), "google.protobuf.")
+}
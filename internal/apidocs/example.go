@@ -0,0 +1,116 @@
+package apidocs
+
+import (
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/fernferret/wab/internal/validate"
+)
+
+// example is md filled with placeholder values, encoded with protojson. Every
+// field shows up, well-known types and recursive fields as null.
+func example(md protoreflect.MessageDescriptor) string {
+	msg := dynamicpb.NewMessage(md)
+	fill(msg, map[protoreflect.FullName]bool{md.FullName(): true})
+
+	data, err := protojson.MarshalOptions{
+		Multiline:       true,
+		Indent:          "  ",
+		EmitUnpopulated: true,
+	}.Marshal(msg)
+	if err != nil {
+		return ""
+	}
+
+	return string(data)
+}
+
+// fill sets every field of msg, seen holds the messages being filled further
+// up so recursive types end.
+func fill(msg protoreflect.Message, seen map[protoreflect.FullName]bool) {
+	fields := msg.Descriptor().Fields()
+
+	for idx := 0; idx < fields.Len(); idx++ {
+		fd := fields.Get(idx)
+
+		// Only the first field of a oneof can be set.
+		if oneof := fd.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() && msg.WhichOneof(oneof) != nil {
+			continue
+		}
+
+		switch {
+		case fd.IsMap():
+			entries := msg.Mutable(fd).Map()
+
+			key, _ := sample(fd.MapKey(), nil, seen)
+			if value, ok := sample(fd.MapValue(), entries.NewValue, seen); ok {
+				entries.Set(key.MapKey(), value)
+			}
+		case fd.IsList():
+			list := msg.Mutable(fd).List()
+			if value, ok := sample(fd, list.NewElement, seen); ok {
+				list.Append(value)
+			}
+		default:
+			newField := func() protoreflect.Value { return msg.NewField(fd) }
+			if value, ok := sample(fd, newField, seen); ok {
+				msg.Set(fd, value)
+			}
+		}
+	}
+}
+
+// sample is the placeholder for a single value of fd, newMessage creates the
+// value of message fields. It returns false for values that are left out.
+func sample(fd protoreflect.FieldDescriptor, newMessage func() protoreflect.Value, seen map[protoreflect.FullName]bool) (protoreflect.Value, bool) {
+	number := int64(1)
+	if rules := validate.Rules(fd); rules.GetMin() > number {
+		number = rules.GetMin()
+	}
+
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(true), true
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		for idx := 0; idx < values.Len(); idx++ {
+			if values.Get(idx).Number() != 0 {
+				return protoreflect.ValueOfEnum(values.Get(idx).Number()), true
+			}
+		}
+
+		return protoreflect.ValueOfEnum(0), true
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(int32(number)), true
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(number), true
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(uint32(number)), true
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(uint64(number)), true
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(1.5), true
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(1.5), true
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(string(fd.Name())), true
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(fd.Name())), true
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		md := fd.Message()
+		if wellKnown(md.FullName()) || seen[md.FullName()] {
+			return protoreflect.Value{}, false
+		}
+
+		value := newMessage()
+
+		seen[md.FullName()] = true
+		fill(value.Message(), seen)
+		delete(seen, md.FullName())
+
+		return value, true
+	}
+
+	return protoreflect.Value{}, false
+}
@@ -0,0 +1,82 @@
+// Package apierr is the shared error model for every transport WAB serves.
+// Handlers build gRPC statuses with google.rpc error details attached, which
+// travel untouched over native gRPC and in the grpcweb trailers, and are
+// rendered as RFC 7807 problem documents on the HTTP/JSON side.
+package apierr
+
+import (
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Domain is the google.rpc.ErrorInfo domain attached to every error WAB
+// creates.
+const Domain = "wab.fernferret.github.com"
+
+// DefaultLocale is the locale of the LocalizedMessage attached by New.
+const DefaultLocale = "en-US"
+
+// Reasons are the stable, machine readable error codes clients can switch on.
+// They're sent as the ErrorInfo reason over gRPC and the "code" member of a
+// problem document over HTTP. Never change the value of an existing reason.
+const (
+	ReasonInvalidRequest   = "INVALID_REQUEST"
+	ReasonMalformedRequest = "MALFORMED_REQUEST"
+	ReasonMissingGreeting  = "MISSING_GREETING"
+	ReasonRequestCancelled = "REQUEST_CANCELLED"
+	ReasonInternal         = "INTERNAL"
+)
+
+// New builds a status error with an ErrorInfo (using reason as the stable
+// code) and a LocalizedMessage, plus any extra details such as BadRequest or
+// RetryInfo.
+func New(code codes.Code, reason, msg string, details ...protoadapt.MessageV1) error {
+	st := status.New(code, msg)
+
+	all := append([]protoadapt.MessageV1{
+		&errdetails.ErrorInfo{Reason: reason, Domain: Domain},
+		&errdetails.LocalizedMessage{Locale: DefaultLocale, Message: msg},
+	}, details...)
+
+	detailed, err := st.WithDetails(all...)
+	if err != nil {
+		// Only happens if a detail can't be marshalled, the bare status is still
+		// better than nothing.
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// BadRequest builds an InvalidArgument error listing every invalid field.
+func BadRequest(reason, msg string, violations ...*errdetails.BadRequest_FieldViolation) error {
+	return New(codes.InvalidArgument, reason, msg, &errdetails.BadRequest{FieldViolations: violations})
+}
+
+// Violation is a shortcut for building a single BadRequest field violation.
+func Violation(field, desc string) *errdetails.BadRequest_FieldViolation {
+	return &errdetails.BadRequest_FieldViolation{Field: field, Description: desc}
+}
+
+// RetryAfter builds a RetryInfo detail telling the client how long to wait
+// before trying again. Over HTTP it's also sent as the Retry-After header.
+func RetryAfter(delay time.Duration) *errdetails.RetryInfo {
+	return &errdetails.RetryInfo{RetryDelay: durationpb.New(delay)}
+}
+
+// Reason returns the stable code of st. This is the ErrorInfo reason if one
+// is attached, otherwise the name of the gRPC code, like "NOT_FOUND".
+func Reason(st *status.Status) string {
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.GetReason() != "" {
+			return info.GetReason()
+		}
+	}
+
+	return codeName(st.Code())
+}
@@ -0,0 +1,152 @@
+package apierr
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// ProblemContentType is the media type of an RFC 7807 problem document.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem document. On top of the standard members it
+// carries the stable error code, the gRPC status code (when there is one) and
+// the google.rpc error details in their protojson form, so the UI can treat
+// HTTP and grpcweb errors the same way.
+type Problem struct {
+	Type       string            `json:"type"`
+	Title      string            `json:"title"`
+	Status     int               `json:"status"`
+	Detail     string            `json:"detail,omitempty"`
+	Instance   string            `json:"instance,omitempty"`
+	Code       string            `json:"code"`
+	GRPCStatus codes.Code        `json:"grpc_status,omitempty"`
+	Details    []json.RawMessage `json:"details,omitempty"`
+
+	retryAfter time.Duration
+}
+
+// StatusProblem converts a gRPC status into a problem document.
+func StatusProblem(st *status.Status) *Problem {
+	code := HTTPStatus(st.Code())
+	problem := &Problem{
+		Type:       "about:blank",
+		Title:      http.StatusText(code),
+		Status:     code,
+		Detail:     st.Message(),
+		Code:       Reason(st),
+		GRPCStatus: st.Code(),
+	}
+
+	for _, detail := range st.Proto().GetDetails() {
+		raw, err := protojson.Marshal(detail)
+		if err != nil {
+			continue
+		}
+
+		problem.Details = append(problem.Details, raw)
+	}
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			problem.retryAfter = info.GetRetryDelay().AsDuration()
+		}
+	}
+
+	return problem
+}
+
+// HTTPProblem builds a problem document for a plain HTTP error, like the ones
+// echo returns for unknown routes. The code is derived from the status text,
+// for example "METHOD_NOT_ALLOWED".
+func HTTPProblem(code int, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(code),
+		Status: code,
+		Detail: detail,
+		Code:   strings.ToUpper(strings.ReplaceAll(http.StatusText(code), " ", "_")),
+	}
+}
+
+// Write sends the problem document, setting the Retry-After header when the
+// error carried a RetryInfo detail.
+func Write(resp http.ResponseWriter, problem *Problem) error {
+	resp.Header().Set("Content-Type", ProblemContentType)
+
+	if problem.retryAfter > 0 {
+		resp.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(problem.retryAfter.Seconds()))))
+	}
+
+	resp.WriteHeader(problem.Status)
+
+	return json.NewEncoder(resp).Encode(problem)
+}
+
+var codeNames = map[codes.Code]string{
+	codes.OK:                 "OK",
+	codes.Canceled:           "CANCELLED",
+	codes.Unknown:            "UNKNOWN",
+	codes.InvalidArgument:    "INVALID_ARGUMENT",
+	codes.DeadlineExceeded:   "DEADLINE_EXCEEDED",
+	codes.NotFound:           "NOT_FOUND",
+	codes.AlreadyExists:      "ALREADY_EXISTS",
+	codes.PermissionDenied:   "PERMISSION_DENIED",
+	codes.ResourceExhausted:  "RESOURCE_EXHAUSTED",
+	codes.FailedPrecondition: "FAILED_PRECONDITION",
+	codes.Aborted:            "ABORTED",
+	codes.OutOfRange:         "OUT_OF_RANGE",
+	codes.Unimplemented:      "UNIMPLEMENTED",
+	codes.Internal:           "INTERNAL",
+	codes.Unavailable:        "UNAVAILABLE",
+	codes.DataLoss:           "DATA_LOSS",
+	codes.Unauthenticated:    "UNAUTHENTICATED",
+}
+
+func codeName(code codes.Code) string {
+	if name, ok := codeNames[code]; ok {
+		return name
+	}
+
+	return codeNames[codes.Unknown]
+}
+
+// HTTPStatus maps a gRPC code to the closest HTTP status, following the
+// mapping in google/rpc/code.proto.
+func HTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		// Not an official status, but it's what nginx and grpc-gateway use.
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,149 @@
+package connect
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"mime"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Envelope flags used by streaming calls.
+const (
+	flagCompressed = 0b00000001
+	flagEndStream  = 0b00000010
+)
+
+// codec encodes messages in one of the two Connect formats.
+type codec struct {
+	name      string
+	marshal   func(proto.Message) ([]byte, error)
+	unmarshal func([]byte, proto.Message) error
+}
+
+var (
+	jsonCodec = &codec{
+		name:    "json",
+		marshal: protojson.Marshal,
+		// Unknown fields are dropped so older servers keep working with newer
+		// clients.
+		unmarshal: protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal,
+	}
+	protoCodec = &codec{
+		name:      "proto",
+		marshal:   proto.Marshal,
+		unmarshal: proto.Unmarshal,
+	}
+)
+
+// codecFor picks the codec for a content type. Unary calls use
+// "application/<codec>" and streaming calls "application/connect+<codec>".
+func codecFor(contentType string, streaming bool) (*codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	prefix := "application/"
+	if streaming {
+		prefix = "application/connect+"
+	}
+
+	switch mediaType {
+	case prefix + "json":
+		return jsonCodec, true
+	case prefix + "proto":
+		return protoCodec, true
+	default:
+		return nil, false
+	}
+}
+
+func (c *codec) contentType(streaming bool) string {
+	if streaming {
+		return "application/connect+" + c.name
+	}
+
+	return "application/" + c.name
+}
+
+// decompress undoes the request compression. Only gzip is supported, which
+// is the one every Connect client implements.
+func decompress(data []byte, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", "identity":
+		return data, nil
+	case "gzip":
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid gzip data: %v", err)
+		}
+
+		out, err := io.ReadAll(io.LimitReader(reader, maxMessageSize+1))
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid gzip data: %v", err)
+		}
+
+		if len(out) > maxMessageSize {
+			return nil, status.Errorf(codes.ResourceExhausted, "message is larger than %d bytes", maxMessageSize)
+		}
+
+		return out, nil
+	default:
+		return nil, status.Errorf(codes.Unimplemented, "unsupported compression %q", encoding)
+	}
+}
+
+// readEnvelope reads a single enveloped message from a streaming request. It
+// returns io.EOF once the body is exhausted.
+func readEnvelope(body io.Reader, encoding string) ([]byte, error) {
+	var prefix [5]byte
+
+	if _, err := io.ReadFull(body, prefix[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+
+		return nil, status.Errorf(codes.InvalidArgument, "incomplete envelope: %v", err)
+	}
+
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxMessageSize {
+		return nil, status.Errorf(codes.ResourceExhausted, "message is larger than %d bytes", maxMessageSize)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(body, data); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "incomplete envelope: %v", err)
+	}
+
+	if prefix[0]&flagCompressed == 0 {
+		return data, nil
+	}
+
+	if encoding == "" || encoding == "identity" {
+		return nil, status.Error(codes.InvalidArgument, "compressed message without a Connect-Content-Encoding")
+	}
+
+	return decompress(data, encoding)
+}
+
+// writeEnvelope sends a single enveloped message.
+func writeEnvelope(w io.Writer, flags byte, data []byte) error {
+	prefix := [5]byte{flags}
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(data)))
+
+	if _, err := w.Write(prefix[:]); err != nil {
+		return fmt.Errorf("failed to write envelope: %w", err)
+	}
+
+	_, err := w.Write(data)
+
+	return err
+}
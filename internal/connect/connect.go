@@ -0,0 +1,165 @@
+// Package connect serves the registered gRPC services over the Connect
+// protocol (https://connectrpc.com/docs/protocol), which is what the newer
+// Connect clients use. Unary calls are plain POSTs with a JSON or binary body,
+// streaming calls use Connect's own envelope. Every call is dispatched through
+// a grpc.ClientConnInterface, normally the in-process channel.
+package connect
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Connect request headers.
+const (
+	headerProtocolVersion = "Connect-Protocol-Version"
+	headerTimeout         = "Connect-Timeout-Ms"
+)
+
+// maxMessageSize matches the default receive limit of a gRPC server.
+const maxMessageSize = 4 << 20
+
+// Router is the part of echo.Echo (and echo.Group) used to register routes.
+type Router interface {
+	Add(method, path string, handler echo.HandlerFunc, middleware ...echo.MiddlewareFunc) *echo.Route
+}
+
+// Handler serves every method of a set of services over the Connect protocol.
+type Handler struct {
+	conn    grpc.ClientConnInterface
+	methods []*method
+	log     *zap.SugaredLogger
+}
+
+type method struct {
+	desc       protoreflect.MethodDescriptor
+	fullMethod string
+	input      protoreflect.MessageType
+	output     protoreflect.MessageType
+	alias      bool
+}
+
+func (m *method) streaming() bool {
+	return m.desc.IsStreamingClient() || m.desc.IsStreamingServer()
+}
+
+// New collects every method in services. Calls are sent to conn.
+func New(conn grpc.ClientConnInterface, services ...protoreflect.ServiceDescriptor) (*Handler, error) {
+	handler := &Handler{
+		conn: conn,
+		log:  zap.S().With("part", "connect"),
+	}
+
+	for _, sd := range services {
+		methods := sd.Methods()
+		for idx := 0; idx < methods.Len(); idx++ {
+			md := methods.Get(idx)
+
+			input, err := protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", md.FullName(), err)
+			}
+
+			output, err := protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", md.FullName(), err)
+			}
+
+			handler.methods = append(handler.methods, &method{
+				desc:       md,
+				fullMethod: fmt.Sprintf("/%s/%s", sd.FullName(), md.Name()),
+				input:      input,
+				output:     output,
+			})
+		}
+	}
+
+	return handler, nil
+}
+
+// Alias serves every method of the service target a second time under the
+// service name alias. Calls are sent to /<alias>/<method>, so conn needs the
+// alias registered too.
+func (h *Handler) Alias(alias, target string) {
+	for _, m := range h.methods {
+		if m.alias || string(m.desc.Parent().FullName()) != target {
+			continue
+		}
+
+		aliased := *m
+		aliased.fullMethod = fmt.Sprintf("/%s/%s", alias, m.desc.Name())
+		aliased.alias = true
+		h.methods = append(h.methods, &aliased)
+	}
+}
+
+// Methods returns the full name of every method served, aliases aside.
+func (h *Handler) Methods() []string {
+	names := make([]string, 0, len(h.methods))
+	for _, method := range h.methods {
+		if !method.alias {
+			names = append(names, string(method.desc.FullName()))
+		}
+	}
+
+	return names
+}
+
+// Register adds a POST route for every method under prefix, for example
+// "<prefix>/wab.greeter.v1.Greeter/Greet".
+func (h *Handler) Register(router Router, prefix string) {
+	for _, method := range h.methods {
+		h.log.Debugf("Connect %s%s", prefix, method.fullMethod)
+
+		if method.streaming() {
+			router.Add(http.MethodPost, prefix+method.fullMethod, h.handleStream(method))
+		} else {
+			router.Add(http.MethodPost, prefix+method.fullMethod, h.handleUnary(method))
+		}
+	}
+}
+
+// callContext applies the Connect-Timeout-Ms header to the request context.
+func callContext(req *http.Request) (context.Context, context.CancelFunc, error) {
+	ctx := req.Context()
+
+	if version := req.Header.Get(headerProtocolVersion); version != "" && version != "1" {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "unsupported %s %q", headerProtocolVersion, version)
+	}
+
+	raw := req.Header.Get(headerTimeout)
+	if raw == "" {
+		ctx, cancel := context.WithCancel(ctx)
+
+		return ctx, cancel, nil
+	}
+
+	millis, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || millis < 0 || len(raw) > 10 {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "invalid %s %q", headerTimeout, raw)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(millis)*time.Millisecond)
+
+	return ctx, cancel, nil
+}
+
+// unsupportedMediaType answers with a 415, which is what Connect clients
+// expect when they pick a codec the server doesn't know.
+func unsupportedMediaType(ectx echo.Context, accepted ...string) error {
+	ectx.Response().Header().Set("Accept-Post", strings.Join(accepted, ", "))
+
+	return ectx.NoContent(http.StatusUnsupportedMediaType)
+}
@@ -0,0 +1,74 @@
+package connect
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// wireError is the Connect JSON error format. It's the whole body of a failed
+// unary call and the "error" member of the end-of-stream message.
+type wireError struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message,omitempty"`
+	Details []wireDetail `json:"details,omitempty"`
+}
+
+// wireDetail is a google.rpc error detail. Value holds the binary message
+// (base64 without padding), Debug the protojson form for humans.
+type wireDetail struct {
+	Type  string          `json:"type"`
+	Value string          `json:"value"`
+	Debug json.RawMessage `json:"debug,omitempty"`
+}
+
+var connectCodes = map[codes.Code]string{
+	codes.Canceled:           "canceled",
+	codes.Unknown:            "unknown",
+	codes.InvalidArgument:    "invalid_argument",
+	codes.DeadlineExceeded:   "deadline_exceeded",
+	codes.NotFound:           "not_found",
+	codes.AlreadyExists:      "already_exists",
+	codes.PermissionDenied:   "permission_denied",
+	codes.ResourceExhausted:  "resource_exhausted",
+	codes.FailedPrecondition: "failed_precondition",
+	codes.Aborted:            "aborted",
+	codes.OutOfRange:         "out_of_range",
+	codes.Unimplemented:      "unimplemented",
+	codes.Internal:           "internal",
+	codes.Unavailable:        "unavailable",
+	codes.DataLoss:           "data_loss",
+	codes.Unauthenticated:    "unauthenticated",
+}
+
+func newWireError(st *status.Status) *wireError {
+	code, ok := connectCodes[st.Code()]
+	if !ok {
+		code = connectCodes[codes.Unknown]
+	}
+
+	wire := &wireError{Code: code, Message: st.Message()}
+
+	for _, detail := range st.Proto().GetDetails() {
+		typeURL := detail.GetTypeUrl()
+
+		out := wireDetail{
+			Type:  typeURL[strings.LastIndex(typeURL, "/")+1:],
+			Value: base64.RawStdEncoding.EncodeToString(detail.GetValue()),
+		}
+
+		if msg, err := detail.UnmarshalNew(); err == nil {
+			if debug, err := protojson.Marshal(msg); err == nil {
+				out.Debug = debug
+			}
+		}
+
+		wire.Details = append(wire.Details, out)
+	}
+
+	return wire
+}
@@ -0,0 +1,195 @@
+package connect
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const headerContentEncoding = "Connect-Content-Encoding"
+
+// maxBufferedRequest caps how much of a streaming request is buffered for
+// HTTP/1.x clients.
+const maxBufferedRequest = 4 * maxMessageSize
+
+// endStream is the JSON message sent in the final envelope of every stream.
+type endStream struct {
+	Error    *wireError  `json:"error,omitempty"`
+	Metadata metadata.MD `json:"metadata,omitempty"`
+}
+
+// handleStream serves client, server and bidi streaming methods. Requests are
+// forwarded from a separate goroutine so bidi methods that answer each message
+// as it arrives (like GreetStream) don't deadlock.
+func (h *Handler) handleStream(m *method) echo.HandlerFunc {
+	return func(ectx echo.Context) error {
+		req := ectx.Request()
+
+		codec, ok := codecFor(req.Header.Get(echo.HeaderContentType), true)
+		if !ok {
+			return unsupportedMediaType(ectx, "application/connect+json", "application/connect+proto")
+		}
+
+		// HTTP/1.x can't read the request body once the response has started,
+		// so the requests are buffered up front. This makes bidi calls
+		// half-duplex unless the client uses HTTP/2.
+		var body io.Reader = req.Body
+
+		if req.ProtoMajor < 2 {
+			data, err := io.ReadAll(io.LimitReader(req.Body, maxBufferedRequest+1))
+			if err == nil && len(data) > maxBufferedRequest {
+				err = status.Errorf(codes.ResourceExhausted, "streaming request is larger than %d bytes", maxBufferedRequest)
+			}
+
+			if err != nil {
+				return h.endStream(startStream(ectx, codec), status.Convert(err).Err(), nil)
+			}
+
+			body = bytes.NewReader(data)
+		}
+
+		resp := startStream(ectx, codec)
+
+		ctx, cancel, err := callContext(req)
+		if err != nil {
+			return h.endStream(resp, err, nil)
+		}
+		defer cancel()
+
+		desc := &grpc.StreamDesc{
+			ClientStreams: m.desc.IsStreamingClient(),
+			ServerStreams: m.desc.IsStreamingServer(),
+		}
+
+		stream, err := h.conn.NewStream(ctx, desc, m.fullMethod)
+		if err != nil {
+			return h.endStream(resp, err, nil)
+		}
+
+		// If the request can't be decoded the call is cancelled, and the
+		// decoding error is reported instead of the cancellation. The error is
+		// queued before cancelling so it's always there once RecvMsg fails.
+		sendErr := make(chan error, 1)
+
+		go func() {
+			err := h.forwardRequests(m, codec, body, req.Header.Get(headerContentEncoding), stream)
+			if err != nil {
+				sendErr <- err
+
+				cancel()
+			}
+		}()
+
+		for {
+			out := m.output.New().Interface()
+
+			err := stream.RecvMsg(out)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+
+				select {
+				case fwdErr := <-sendErr:
+					err = fwdErr
+				default:
+				}
+
+				return h.endStream(resp, err, stream.Trailer())
+			}
+
+			data, err := codec.marshal(out)
+			if err != nil {
+				cancel()
+
+				return h.endStream(resp, status.Errorf(codes.Internal, "unable to encode response: %v", err), nil)
+			}
+
+			if err := writeEnvelope(resp, 0, data); err != nil {
+				// The client is gone, cancelling the context ends the call.
+				return nil
+			}
+
+			resp.Flush()
+		}
+	}
+}
+
+// forwardRequests decodes every enveloped request and sends it on the stream.
+// Methods that aren't client-streaming must get exactly one request.
+func (h *Handler) forwardRequests(m *method, codec *codec, body io.Reader, encoding string, stream grpc.ClientStream) error {
+	count := 0
+
+	for {
+		data, err := readEnvelope(body, encoding)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		count++
+
+		if !m.desc.IsStreamingClient() && count > 1 {
+			return status.Error(codes.InvalidArgument, "server-streaming methods take a single request message")
+		}
+
+		in := m.input.New().Interface()
+		if err := codec.unmarshal(data, in); err != nil {
+			return status.Errorf(codes.InvalidArgument, "unable to decode request: %v", err)
+		}
+
+		if err := stream.SendMsg(in); err != nil {
+			// The server already finished, the real status comes from RecvMsg.
+			return nil
+		}
+	}
+
+	if !m.desc.IsStreamingClient() && count == 0 {
+		return status.Error(codes.InvalidArgument, "missing request message")
+	}
+
+	return stream.CloseSend()
+}
+
+// startStream sends the response headers. Streams always answer with a 200,
+// the real status is in the end-of-stream message.
+func startStream(ectx echo.Context, codec *codec) *echo.Response {
+	resp := ectx.Response()
+	resp.Header().Set(echo.HeaderContentType, codec.contentType(true))
+	resp.WriteHeader(http.StatusOK)
+
+	return resp
+}
+
+// endStream sends the final envelope with the call's status and trailers.
+func (h *Handler) endStream(resp *echo.Response, err error, trailer metadata.MD) error {
+	msg := endStream{Metadata: trailer}
+
+	if err != nil {
+		msg.Error = newWireError(status.Convert(err))
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	if err := writeEnvelope(resp, flagEndStream, data); err != nil {
+		return nil
+	}
+
+	resp.Flush()
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package connect
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/fernferret/wab/internal/apierr"
+)
+
+func (h *Handler) handleUnary(m *method) echo.HandlerFunc {
+	return func(ectx echo.Context) error {
+		req := ectx.Request()
+
+		codec, ok := codecFor(req.Header.Get(echo.HeaderContentType), false)
+		if !ok {
+			return unsupportedMediaType(ectx, "application/json", "application/proto")
+		}
+
+		ctx, cancel, err := callContext(req)
+		if err != nil {
+			return writeUnaryError(ectx, err)
+		}
+		defer cancel()
+
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxMessageSize+1))
+		if err != nil {
+			return writeUnaryError(ectx, status.Errorf(codes.InvalidArgument, "unable to read request: %v", err))
+		}
+
+		if len(body) > maxMessageSize {
+			return writeUnaryError(ectx, status.Errorf(codes.ResourceExhausted, "message is larger than %d bytes", maxMessageSize))
+		}
+
+		body, err = decompress(body, req.Header.Get(echo.HeaderContentEncoding))
+		if err != nil {
+			return writeUnaryError(ectx, err)
+		}
+
+		in := m.input.New().Interface()
+		if err := codec.unmarshal(body, in); err != nil {
+			return writeUnaryError(ectx, status.Errorf(codes.InvalidArgument, "unable to decode request: %v", err))
+		}
+
+		out := m.output.New().Interface()
+		if err := h.conn.Invoke(ctx, m.fullMethod, in, out); err != nil {
+			return writeUnaryError(ectx, err)
+		}
+
+		data, err := codec.marshal(out)
+		if err != nil {
+			return writeUnaryError(ectx, status.Errorf(codes.Internal, "unable to encode response: %v", err))
+		}
+
+		return ectx.Blob(http.StatusOK, codec.contentType(false), data)
+	}
+}
+
+// writeUnaryError sends a Connect JSON error. Clients read the code from the
+// body, the HTTP status follows the usual gRPC to HTTP mapping and is only a
+// fallback for proxies and humans.
+func writeUnaryError(ectx echo.Context, err error) error {
+	st := status.Convert(err)
+
+	return ectx.JSON(apierr.HTTPStatus(st.Code()), newWireError(st))
+}
@@ -0,0 +1,175 @@
+// Package connlimit caps the connections a listener keeps open, in total and
+// per client address.
+package connlimit
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+// ErrPerIP is what reading from or writing to a connection over the PerIP cap
+// returns, the connection is closed.
+var ErrPerIP = errors.New("too many connections from this address")
+
+// Limits are the connection caps, 0 means no cap.
+type Limits struct {
+	// Total is the most connections open at once. Accept waits for one to
+	// close when it's reached, new clients queue in the listen backlog.
+	Total int
+	// PerIP is the most connections from one address, more are closed when
+	// they're first used. The address is the connection's RemoteAddr at that
+	// point, in the connection's own goroutine, so a PROXY protocol listener
+	// wrapped by this one has already replaced the proxy's address with the
+	// client's.
+	PerIP int
+	// Exempt reports the addresses PerIP doesn't apply to, like trusted
+	// proxies that carry many clients on their own address. Nil exempts none.
+	Exempt func(net.IP) bool
+}
+
+// Listener applies the limits to inner, it's returned as is without any.
+func (l Limits) Listener(inner net.Listener) net.Listener {
+	if l.Total <= 0 && l.PerIP <= 0 {
+		return inner
+	}
+
+	lis := &listener{Listener: inner, limits: l, perIP: map[string]int{}}
+	if l.Total > 0 {
+		lis.slots = make(chan struct{}, l.Total)
+	}
+
+	return lis
+}
+
+type listener struct {
+	net.Listener
+	limits Limits
+	slots  chan struct{}
+
+	mu    sync.Mutex
+	perIP map[string]int
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	if l.slots != nil {
+		l.slots <- struct{}{}
+	}
+
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		l.release()
+		return nil, err
+	}
+
+	return &limitedConn{Conn: conn, listener: l}, nil
+}
+
+// acquire counts a connection from addr. ip is the address counted, empty
+// when addr isn't capped, ok is false when addr is at its limit.
+func (l *listener) acquire(addr net.Addr) (ip string, ok bool) {
+	if l.limits.PerIP <= 0 {
+		return "", true
+	}
+
+	ip = host(addr)
+	if l.limits.Exempt != nil && l.limits.Exempt(net.ParseIP(ip)) {
+		return "", true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.perIP[ip] >= l.limits.PerIP {
+		return "", false
+	}
+
+	l.perIP[ip]++
+
+	return ip, true
+}
+
+// release frees a slot of the total.
+func (l *listener) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+func (l *listener) closed(ip string) {
+	if ip != "" {
+		l.mu.Lock()
+
+		if l.perIP[ip]--; l.perIP[ip] <= 0 {
+			delete(l.perIP, ip)
+		}
+
+		l.mu.Unlock()
+	}
+
+	l.release()
+}
+
+type limitedConn struct {
+	net.Conn
+	listener *listener
+
+	admitOnce sync.Once
+	// ip is the address counted against PerIP, err is why the connection
+	// can't be used.
+	ip  string
+	err error
+
+	closeOnce sync.Once
+}
+
+// admit counts the connection against its address on first use.
+func (c *limitedConn) admit() error {
+	c.admitOnce.Do(func() {
+		var ok bool
+		if c.ip, ok = c.listener.acquire(c.Conn.RemoteAddr()); !ok {
+			c.err = ErrPerIP
+		}
+	})
+
+	if c.err == ErrPerIP {
+		_ = c.Close()
+	}
+
+	return c.err
+}
+
+func (c *limitedConn) Read(b []byte) (int, error) {
+	if err := c.admit(); err != nil {
+		return 0, err
+	}
+
+	return c.Conn.Read(b)
+}
+
+func (c *limitedConn) Write(b []byte) (int, error) {
+	if err := c.admit(); err != nil {
+		return 0, err
+	}
+
+	return c.Conn.Write(b)
+}
+
+func (c *limitedConn) Close() error {
+	// A connection closed before its first use isn't counted anymore.
+	c.admitOnce.Do(func() { c.err = net.ErrClosed })
+
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() { c.listener.closed(c.ip) })
+
+	return err
+}
+
+func host(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+
+	return host
+}
@@ -0,0 +1,170 @@
+package connlimit
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type fakeAddr string
+
+func (a fakeAddr) Network() string { return "tcp" }
+func (a fakeAddr) String() string  { return string(a) }
+
+// fakeConn is a connection from remote, closed records whether the listener
+// turned it away.
+type fakeConn struct {
+	net.Conn
+	remote net.Addr
+	closed bool
+}
+
+func (c *fakeConn) RemoteAddr() net.Addr { return c.remote }
+
+func (c *fakeConn) Read([]byte) (int, error) { return 0, nil }
+
+func (c *fakeConn) Close() error {
+	c.closed = true
+
+	return nil
+}
+
+// fakeListener hands out the connections sent to conns.
+type fakeListener struct {
+	net.Listener
+	conns chan *fakeConn
+}
+
+func (l *fakeListener) Accept() (net.Conn, error) {
+	return <-l.conns, nil
+}
+
+func TestPerIP(t *testing.T) {
+	inner := &fakeListener{conns: make(chan *fakeConn, 1)}
+	lis := Limits{
+		PerIP:  2,
+		Exempt: net.ParseIP("10.0.0.1").Equal,
+	}.Listener(inner)
+
+	// dial accepts a connection from remote and reads from it.
+	dial := func(remote string) (net.Conn, *fakeConn, error) {
+		t.Helper()
+
+		fake := &fakeConn{remote: fakeAddr(remote)}
+		inner.conns <- fake
+
+		conn, err := lis.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = conn.Read(nil)
+
+		return conn, fake, err
+	}
+
+	first, _, _ := dial("192.0.2.1:1000")
+	dial("192.0.2.1:1001")
+
+	// The third from the same address is closed, another address is fine.
+	_, rejected, err := dial("192.0.2.1:1002")
+	if !errors.Is(err, ErrPerIP) || !rejected.closed {
+		t.Errorf("got %v, want the connection over the limit closed", err)
+	}
+
+	if _, _, err := dial("[2001:db8::1]:1000"); err != nil {
+		t.Errorf("got %v from another address", err)
+	}
+
+	// Closing a connection frees its slot, closing it again doesn't free
+	// another.
+	_ = first.Close()
+	_ = first.Close()
+
+	if _, _, err := dial("192.0.2.1:1003"); err != nil {
+		t.Errorf("got %v after the release", err)
+	}
+
+	if _, _, err := dial("192.0.2.1:1004"); !errors.Is(err, ErrPerIP) {
+		t.Errorf("got %v, a double close freed a second slot", err)
+	}
+
+	// Exempt addresses aren't capped.
+	for port := 1000; port < 1005; port++ {
+		if _, _, err := dial(net.JoinHostPort("10.0.0.1", strconv.Itoa(port))); err != nil {
+			t.Errorf("got %v from an exempt address", err)
+		}
+	}
+}
+
+func TestClosedBeforeUse(t *testing.T) {
+	inner := &fakeListener{conns: make(chan *fakeConn, 3)}
+	lis := Limits{PerIP: 1}.Listener(inner)
+
+	for idx := 0; idx < 3; idx++ {
+		inner.conns <- &fakeConn{remote: fakeAddr("192.0.2.1:1000")}
+	}
+
+	unused, _ := lis.Accept()
+	_ = unused.Close()
+
+	if _, err := unused.Read(nil); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("got %v reading a closed connection, want net.ErrClosed", err)
+	}
+
+	// The unused connection was never counted.
+	used, _ := lis.Accept()
+	if _, err := used.Read(nil); err != nil {
+		t.Errorf("got %v, want the slot free", err)
+	}
+
+	over, _ := lis.Accept()
+	if _, err := over.Read(nil); !errors.Is(err, ErrPerIP) {
+		t.Errorf("got %v, want ErrPerIP", err)
+	}
+}
+
+func TestTotal(t *testing.T) {
+	inner := &fakeListener{conns: make(chan *fakeConn, 2)}
+	lis := Limits{Total: 1}.Listener(inner)
+
+	inner.conns <- &fakeConn{remote: fakeAddr("192.0.2.1:1000")}
+	inner.conns <- &fakeConn{remote: fakeAddr("192.0.2.2:1000")}
+
+	first, err := lis.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accepted := make(chan net.Conn)
+	go func() {
+		conn, _ := lis.Accept()
+		accepted <- conn
+	}()
+
+	select {
+	case <-accepted:
+		t.Fatalf("a second connection was accepted over the total")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_ = first.Close()
+
+	select {
+	case conn := <-accepted:
+		if got := conn.RemoteAddr().String(); got != "192.0.2.2:1000" {
+			t.Errorf("got %s, want the waiting connection", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("closing a connection didn't free its slot")
+	}
+}
+
+func TestNoLimits(t *testing.T) {
+	inner := &fakeListener{}
+	if lis := (Limits{}).Listener(inner); lis != inner {
+		t.Errorf("got a wrapped listener without limits")
+	}
+}
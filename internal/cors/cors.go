@@ -0,0 +1,183 @@
+// Package cors is the cross-origin policy shared by the echo routes, the
+// grpcweb proxy and its websockets, so a split deployment (UI on one origin,
+// API on another) is allowed the same things on every transport.
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// DefaultAllowHeaders are the request headers browsers may send, enough for
+// grpcweb, Connect and the REST routes.
+var DefaultAllowHeaders = []string{
+	"Content-Type",
+	"Authorization",
+	"X-Grpc-Web",
+	"X-User-Agent",
+	"Grpc-Timeout",
+	"Connect-Protocol-Version",
+	"Connect-Timeout-Ms",
+	"Last-Event-ID",
+}
+
+// DefaultExposeHeaders are the response headers scripts may read, grpcweb
+// clients need the status headers when a call fails before any message.
+var DefaultExposeHeaders = []string{
+	"Grpc-Status",
+	"Grpc-Message",
+	"Grpc-Status-Details-Bin",
+	"ETag",
+}
+
+var allowMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// Policy decides which cross-origin requests are allowed.
+type Policy struct {
+	// Origins are the allowed origins. An entry is an exact origin like
+	// https://app.example.com, a wildcard subdomain like https://*.example.com
+	// (which doesn't match https://example.com itself) or * for any origin.
+	Origins []string
+	// AllowHeaders and ExposeHeaders are added to the defaults.
+	AllowHeaders  []string
+	ExposeHeaders []string
+	// AllowCredentials lets browsers send cookies and HTTP auth. It can't be
+	// combined with the * origin.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response.
+	MaxAge time.Duration
+}
+
+// Validate reports malformed origins and unsafe combinations.
+func (p *Policy) Validate() error {
+	for _, origin := range p.Origins {
+		if origin == "*" {
+			if p.AllowCredentials {
+				return errors.New("credentials can't be allowed for the * origin, list the origins instead")
+			}
+
+			continue
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("bad origin %q, expected something like https://app.example.com", origin)
+		}
+
+		if strings.Contains(strings.TrimPrefix(u.Host, "*."), "*") {
+			return fmt.Errorf("bad origin %q, only a leading *. wildcard is supported", origin)
+		}
+	}
+
+	return nil
+}
+
+// Enabled reports whether any cross-origin requests are allowed.
+func (p *Policy) Enabled() bool {
+	return len(p.Origins) > 0
+}
+
+// AllowOrigin reports whether origin matches the policy.
+func (p *Policy) AllowOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return false
+	}
+
+	for _, allowed := range p.Origins {
+		if allowed == "*" {
+			return true
+		}
+
+		a, err := url.Parse(strings.TrimSuffix(allowed, "/"))
+		if err != nil || !strings.EqualFold(a.Scheme, u.Scheme) {
+			continue
+		}
+
+		if suffix, ok := strings.CutPrefix(strings.ToLower(a.Host), "*"); ok {
+			if strings.HasSuffix(strings.ToLower(u.Host), suffix) {
+				return true
+			}
+
+			continue
+		}
+
+		if strings.EqualFold(a.Host, u.Host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowRequest reports whether a browser request may go ahead: requests
+// without an Origin header (not from a browser), same-origin requests and
+// allowed origins. It's what guards the websocket upgrades, which browsers
+// never preflight.
+func (p *Policy) AllowRequest(req *http.Request) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, req.Host) {
+		return true
+	}
+
+	return p.AllowOrigin(origin)
+}
+
+// Headers returns the allowed request headers, the defaults included.
+func (p *Policy) Headers() []string {
+	return append(append([]string{}, DefaultAllowHeaders...), p.AllowHeaders...)
+}
+
+// Middleware answers preflight requests and adds the CORS headers to the
+// responses of allowed origins.
+func (p *Policy) Middleware() echo.MiddlewareFunc {
+	config := middleware.CORSConfig{
+		AllowMethods:     allowMethods,
+		AllowHeaders:     p.Headers(),
+		ExposeHeaders:    append(append([]string{}, DefaultExposeHeaders...), p.ExposeHeaders...),
+		AllowCredentials: p.AllowCredentials,
+		MaxAge:           int(p.MaxAge.Seconds()),
+	}
+
+	// The * origin is answered with a literal *, which browsers never send
+	// credentials to, even when Validate wasn't asked first.
+	if p.anyOrigin() {
+		config.AllowOrigins = []string{"*"}
+		config.AllowCredentials = false
+	} else {
+		config.AllowOriginFunc = func(origin string) (bool, error) {
+			return p.AllowOrigin(origin), nil
+		}
+	}
+
+	return middleware.CORSWithConfig(config)
+}
+
+// anyOrigin reports whether the policy allows the * origin.
+func (p *Policy) anyOrigin() bool {
+	for _, origin := range p.Origins {
+		if origin == "*" {
+			return true
+		}
+	}
+
+	return false
+}
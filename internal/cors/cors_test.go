@@ -0,0 +1,96 @@
+package cors
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllowOrigin(t *testing.T) {
+	cases := []struct {
+		origins []string
+		origin  string
+		allowed bool
+	}{
+		{origins: nil, origin: "https://app.example.com"},
+		{origins: []string{"*"}, origin: "https://app.example.com", allowed: true},
+		{origins: []string{"*"}, origin: "null"},
+		{origins: []string{"https://app.example.com"}, origin: "https://app.example.com", allowed: true},
+		{origins: []string{"https://app.example.com/"}, origin: "https://app.example.com", allowed: true},
+		{origins: []string{"https://app.example.com"}, origin: "https://APP.example.com", allowed: true},
+		{origins: []string{"https://app.example.com"}, origin: "http://app.example.com"},
+		{origins: []string{"https://app.example.com"}, origin: "https://app.example.com:8443"},
+		{origins: []string{"https://app.example.com"}, origin: "https://app.example.com.evil.example"},
+		{origins: []string{"https://*.example.com"}, origin: "https://app.example.com", allowed: true},
+		{origins: []string{"https://*.example.com"}, origin: "https://a.b.example.com", allowed: true},
+		{origins: []string{"https://*.example.com"}, origin: "https://example.com"},
+		{origins: []string{"https://*.example.com"}, origin: "https://evilexample.com"},
+		{origins: []string{"https://*.example.com"}, origin: "http://app.example.com"},
+		{origins: []string{"http://localhost:5173", "https://*.example.com"}, origin: "http://localhost:5173", allowed: true},
+	}
+
+	for _, tc := range cases {
+		policy := &Policy{Origins: tc.origins}
+		if got := policy.AllowOrigin(tc.origin); got != tc.allowed {
+			t.Errorf("%v allowing %s: got %v, want %v", tc.origins, tc.origin, got, tc.allowed)
+		}
+	}
+}
+
+func TestAllowRequest(t *testing.T) {
+	policy := &Policy{Origins: []string{"https://app.example.com"}}
+
+	cases := []struct {
+		origin  string
+		allowed bool
+	}{
+		// Not from a browser.
+		{origin: "", allowed: true},
+		// Same origin as the request's Host.
+		{origin: "http://wab.local:8080", allowed: true},
+		{origin: "https://app.example.com", allowed: true},
+		{origin: "https://evil.example"},
+	}
+
+	for _, tc := range cases {
+		req := httptest.NewRequest("GET", "http://wab.local:8080/grpc/wab.greeter.v1.Greeter/GreetStream", nil)
+		if tc.origin != "" {
+			req.Header.Set("Origin", tc.origin)
+		}
+
+		if got := policy.AllowRequest(req); got != tc.allowed {
+			t.Errorf("origin %q: got %v, want %v", tc.origin, got, tc.allowed)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy Policy
+		valid  bool
+	}{
+		{name: "empty", valid: true},
+		{name: "wildcard", policy: Policy{Origins: []string{"*"}}, valid: true},
+		{name: "wildcard with credentials", policy: Policy{Origins: []string{"*"}, AllowCredentials: true}},
+		{name: "listed with credentials", policy: Policy{Origins: []string{"https://app.example.com"}, AllowCredentials: true}, valid: true},
+		{name: "subdomain wildcard", policy: Policy{Origins: []string{"https://*.example.com"}}, valid: true},
+		{name: "trailing slash", policy: Policy{Origins: []string{"https://app.example.com/"}}, valid: true},
+		{name: "no scheme", policy: Policy{Origins: []string{"app.example.com"}}},
+		{name: "path", policy: Policy{Origins: []string{"https://app.example.com/ui"}}},
+		{name: "inner wildcard", policy: Policy{Origins: []string{"https://app.*.com"}}},
+		{name: "double wildcard", policy: Policy{Origins: []string{"https://*.*.example.com"}}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.Validate()
+			if tc.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if !tc.valid && err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+// Package descriptors is where grpcui, server reflection and the OpenAPI
+// document get their proto descriptors from. The FileDescriptorSet embedded
+// in package proto is preferred because, unlike the descriptors compiled into
+// the generated packages, it keeps the proto comments. Anything missing from
+// the set falls back to protoregistry.GlobalFiles, so imports like the
+// well-known types resolve without listing every file.
+package descriptors
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	wabproto "github.com/fernferret/wab/proto"
+)
+
+// Resolver looks descriptors up in the embedded set first and the
+// compiled-in registry second. It satisfies protodesc.Resolver.
+type Resolver struct {
+	embedded *protoregistry.Files
+}
+
+var (
+	loadOnce sync.Once
+	loaded   *Resolver
+	loadErr  error
+)
+
+// Load returns the resolver for the FileDescriptorSet embedded in package
+// proto. The set is only decoded once.
+func Load() (*Resolver, error) {
+	loadOnce.Do(func() {
+		loaded, loadErr = New(wabproto.DescriptorSet)
+	})
+
+	return loaded, loadErr
+}
+
+// New builds a resolver from a serialized FileDescriptorSet. The set must
+// include its imports (protoc --include_imports), an empty set resolves
+// everything from the compiled-in registry.
+func New(data []byte) (*Resolver, error) {
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("decoding descriptor set: %w", err)
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("loading descriptor set: %w", err)
+	}
+
+	return &Resolver{embedded: files}, nil
+}
+
+// FindFileByPath looks up a file by the path it was compiled with, like
+// "greeter.proto".
+func (r *Resolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if fd, err := r.embedded.FindFileByPath(path); err == nil {
+		return fd, nil
+	}
+
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+// FindDescriptorByName looks up any descriptor by its full name.
+func (r *Resolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d, err := r.embedded.FindDescriptorByName(name); err == nil {
+		return d, nil
+	}
+
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+// Services returns the descriptors of the named services, sorted by name.
+func (r *Resolver) Services(names ...string) ([]protoreflect.ServiceDescriptor, error) {
+	services := make([]protoreflect.ServiceDescriptor, 0, len(names))
+
+	for _, name := range names {
+		d, err := r.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a service", name)
+		}
+
+		services = append(services, sd)
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].FullName() < services[j].FullName()
+	})
+
+	return services, nil
+}
+
+// FileSet returns the files declaring services along with everything they
+// import, dependencies first, which is the order protoc writes them in.
+func FileSet(services ...protoreflect.ServiceDescriptor) *descriptorpb.FileDescriptorSet {
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := map[string]bool{}
+
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+
+		seen[fd.Path()] = true
+
+		imports := fd.Imports()
+		for idx := 0; idx < imports.Len(); idx++ {
+			add(imports.Get(idx).FileDescriptor)
+		}
+
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+	}
+
+	for _, sd := range services {
+		add(sd.ParentFile())
+	}
+
+	return set
+}
@@ -0,0 +1,94 @@
+package descriptors
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	contentTypeBinary = "application/x-protobuf"
+	contentTypeJSON   = "application/json"
+)
+
+// Handler serves a FileDescriptorSet to client code generators, as binary
+// protobuf by default or as JSON when asked for with ?format=json or an
+// Accept: application/json header. Both encodings are built once and carry an
+// ETag derived from the hash of the binary set, so tooling can cheaply check
+// whether the schema changed.
+type Handler struct {
+	binary []byte
+	json   []byte
+	etag   string
+}
+
+// NewHandler encodes set for serving.
+func NewHandler(set *descriptorpb.FileDescriptorSet) (*Handler, error) {
+	binary, err := proto.MarshalOptions{Deterministic: true}.Marshal(set)
+	if err != nil {
+		return nil, fmt.Errorf("encoding descriptor set: %w", err)
+	}
+
+	json, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(set)
+	if err != nil {
+		return nil, fmt.Errorf("encoding descriptor set as JSON: %w", err)
+	}
+
+	sum := sha256.Sum256(binary)
+
+	return &Handler{
+		binary: binary,
+		json:   json,
+		etag:   hex.EncodeToString(sum[:16]),
+	}, nil
+}
+
+// ETag is the hash of the binary set, without quotes.
+func (h *Handler) ETag() string {
+	return h.etag
+}
+
+func (h *Handler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	data, contentType, etag := h.binary, contentTypeBinary, `"`+h.etag+`"`
+	if wantsJSON(req) {
+		// protojson doesn't promise byte-identical output between builds, so
+		// the JSON only gets a weak ETag.
+		data, contentType, etag = h.json, contentTypeJSON, `W/"`+h.etag+`-json"`
+	}
+
+	header := resp.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "no-cache")
+	header.Add("Vary", "Accept")
+
+	// ServeContent answers If-None-Match with a 304 and handles HEAD.
+	http.ServeContent(resp, req, "", time.Time{}, bytes.NewReader(data))
+}
+
+func wantsJSON(req *http.Request) bool {
+	switch req.URL.Query().Get("format") {
+	case "json":
+		return true
+	case "binary", "proto":
+		return false
+	}
+
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == contentTypeJSON {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,309 @@
+// Package openapi describes the REST/JSON routes served by package transcode
+// as an OpenAPI 3 document. Schemas follow the protojson mapping the routes
+// use, (wab.validate.rules) constraints become schema constraints and proto
+// comments become descriptions.
+package openapi
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/fernferret/wab/internal/apierr"
+	"github.com/fernferret/wab/internal/transcode"
+)
+
+const (
+	schemaPrefix = "#/components/schemas/"
+	problemName  = "Problem"
+
+	jsonContentType = "application/json"
+)
+
+// templateVar matches a path template variable like {name} or {name=**}.
+var templateVar = regexp.MustCompile(`\{([^}=]+)(=[^}]*)?\}`)
+
+// Resolver finds the source version of a descriptor, protoregistry.Files and
+// descriptors.Resolver both satisfy it.
+type Resolver interface {
+	FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error)
+}
+
+type generator struct {
+	doc   *Document
+	files Resolver
+	ids   map[string]int
+}
+
+// Generate builds the document for bindings.
+//
+// The compiled-in descriptors the bindings point at carry no comments, so
+// descriptions are looked up by full name in files, which should be loaded
+// with source info. A nil files leaves every description empty.
+func Generate(info Info, bindings []*transcode.Binding, files Resolver) *Document {
+	g := &generator{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   map[string]PathItem{},
+			Components: Components{
+				Schemas: map[string]*Schema{problemName: problemSchema()},
+			},
+		},
+		files: files,
+		ids:   map[string]int{},
+	}
+
+	for _, binding := range bindings {
+		g.addBinding(binding)
+	}
+
+	return g.doc
+}
+
+func (g *generator) addBinding(binding *transcode.Binding) {
+	md := binding.Method
+	sd := md.Parent().(protoreflect.ServiceDescriptor)
+	tag := g.addTag(sd)
+
+	// The first line of the comment is the summary, anything longer is kept
+	// as the description.
+	comment := g.comments(md)
+	summary, _, multiline := strings.Cut(comment, "\n")
+
+	if !multiline {
+		comment = ""
+	}
+
+	op := &Operation{
+		OperationID: g.operationID(sd, md),
+		Tags:        []string{tag},
+		Summary:     summary,
+		Description: comment,
+		Responses: map[string]*Response{
+			"default": {
+				Description: "The call failed.",
+				Content: map[string]*MediaType{
+					apierr.ProblemContentType: {Schema: &Schema{Ref: schemaPrefix + problemName}},
+				},
+			},
+		},
+	}
+
+	bound := map[string]bool{}
+
+	for _, param := range binding.PathParams {
+		bound[param] = true
+
+		fd := fieldByPath(md.Input(), param)
+		schema := g.valueSchema(fd)
+		applyRules(schema, fd)
+
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        param,
+			In:          "path",
+			Description: g.comments(fd),
+			Required:    true,
+			Schema:      schema,
+		})
+	}
+
+	switch binding.Body {
+	case "":
+		op.Parameters = append(op.Parameters, g.queryParams(md.Input(), "", bound, nil)...)
+	case "*":
+		op.RequestBody = jsonBody(g.messageRef(md.Input()))
+	default:
+		bound[binding.Body] = true
+		op.RequestBody = jsonBody(g.fieldSchema(md.Input().Fields().ByName(protoreflect.Name(binding.Body))))
+		op.Parameters = append(op.Parameters, g.queryParams(md.Input(), "", bound, nil)...)
+	}
+
+	resp := g.messageRef(md.Output())
+	if binding.ResponseBody != "" {
+		resp = g.fieldSchema(md.Output().Fields().ByName(protoreflect.Name(binding.ResponseBody)))
+	}
+
+	if binding.ServerStreaming() {
+		op.Responses["200"] = &Response{
+			Description: fmt.Sprintf("A stream of %s messages, one JSON object per line. An error after the "+
+				"first message is sent as a final {\"error\": problem} line.", md.Output().Name()),
+			Content: map[string]*MediaType{transcode.NDJSONContentType: {Schema: resp}},
+		}
+	} else {
+		op.Responses["200"] = &Response{
+			Description: "OK",
+			Content:     map[string]*MediaType{jsonContentType: {Schema: resp}},
+		}
+	}
+
+	path := templateVar.ReplaceAllString(binding.Template, "{$1}")
+	if g.doc.Paths[path] == nil {
+		g.doc.Paths[path] = PathItem{}
+	}
+
+	g.doc.Paths[path][strings.ToLower(binding.Verb)] = op
+}
+
+func jsonBody(schema *Schema) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]*MediaType{jsonContentType: {Schema: schema}},
+	}
+}
+
+// operationID names an operation after its method. Methods with several
+// bindings get a numeric suffix on every binding after the first.
+func (g *generator) operationID(sd protoreflect.ServiceDescriptor, md protoreflect.MethodDescriptor) string {
+	id := fmt.Sprintf("%s_%s", sd.Name(), md.Name())
+
+	count := g.ids[id]
+	g.ids[id]++
+
+	if count > 0 {
+		return fmt.Sprintf("%s_%d", id, count)
+	}
+
+	return id
+}
+
+func (g *generator) addTag(sd protoreflect.ServiceDescriptor) string {
+	name := string(sd.FullName())
+
+	for _, tag := range g.doc.Tags {
+		if tag.Name == name {
+			return name
+		}
+	}
+
+	g.doc.Tags = append(g.doc.Tags, &Tag{Name: name, Description: g.comments(sd)})
+
+	return name
+}
+
+// queryParams lists the scalar fields of md that can be set from the query
+// string, skipping any field already bound to the path or body. Singular
+// message fields are flattened into dotted names.
+func (g *generator) queryParams(md protoreflect.MessageDescriptor, prefix string, bound map[string]bool, seen []protoreflect.FullName) []*Parameter {
+	for _, name := range seen {
+		if name == md.FullName() {
+			return nil
+		}
+	}
+
+	seen = append(seen, md.FullName())
+
+	var params []*Parameter
+
+	fields := md.Fields()
+	for idx := 0; idx < fields.Len(); idx++ {
+		fd := fields.Get(idx)
+		name := prefix + string(fd.Name())
+
+		if bound[name] || fd.IsMap() {
+			continue
+		}
+
+		if fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+			if !fd.IsList() && wellKnown(fd.Message()) == nil {
+				params = append(params, g.queryParams(fd.Message(), name+".", bound, seen)...)
+			}
+
+			continue
+		}
+
+		schema := g.valueSchema(fd)
+		if fd.IsList() {
+			schema = &Schema{Type: "array", Items: schema}
+		} else {
+			applyRules(schema, fd)
+		}
+
+		params = append(params, &Parameter{
+			Name:        name,
+			In:          "query",
+			Description: g.comments(fd),
+			Schema:      schema,
+		})
+	}
+
+	return params
+}
+
+// comments returns the leading (or failing that, trailing) comment of the
+// source version of d.
+func (g *generator) comments(d protoreflect.Descriptor) string {
+	if g.files == nil {
+		return ""
+	}
+
+	src, err := g.files.FindDescriptorByName(d.FullName())
+	if err != nil {
+		return ""
+	}
+
+	loc := src.ParentFile().SourceLocations().ByDescriptor(src)
+
+	text := strings.TrimSpace(loc.LeadingComments)
+	if text == "" {
+		text = strings.TrimSpace(loc.TrailingComments)
+	}
+
+	lines := strings.Split(text, "\n")
+	for idx, line := range lines {
+		lines[idx] = strings.TrimSpace(line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func fieldByPath(md protoreflect.MessageDescriptor, path string) protoreflect.FieldDescriptor {
+	var fd protoreflect.FieldDescriptor
+
+	for _, part := range strings.Split(path, ".") {
+		if fd != nil {
+			md = fd.Message()
+		}
+
+		fd = md.Fields().ByName(protoreflect.Name(part))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(part)
+		}
+	}
+
+	return fd
+}
+
+func problemSchema() *Schema {
+	str := func(desc string) *Schema { return &Schema{Type: "string", Description: desc} }
+
+	return &Schema{
+		Type:        "object",
+		Description: "An RFC 9457 problem document, the body of every error returned by the HTTP routes.",
+		Required:    []string{"type", "title", "status", "code"},
+		Properties: map[string]*Schema{
+			"type":     str("Always about:blank."),
+			"title":    str("The HTTP status text."),
+			"status":   {Type: "integer", Format: "int32", Description: "The HTTP status code."},
+			"detail":   str("A human readable explanation of the error."),
+			"instance": str("The request path."),
+			"code":     str("The gRPC status code name, like INVALID_ARGUMENT."),
+			"grpc_status": {
+				Type:        "integer",
+				Format:      "int32",
+				Description: "The numeric gRPC status code, missing for errors that didn't come from a gRPC method.",
+			},
+			"details": {
+				Type:        "array",
+				Description: "The google.rpc error details attached to the status, in protojson form.",
+				Items: &Schema{
+					Type:                 "object",
+					Properties:           map[string]*Schema{"@type": str("The type URL of the detail.")},
+					AdditionalProperties: &Schema{},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,176 @@
+package openapi
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/fernferret/wab/internal/validate"
+)
+
+// messageRef returns a reference to the component schema of md, adding it
+// (and every message it uses) the first time md is seen. Well-known types
+// are inlined using their special JSON form.
+func (g *generator) messageRef(md protoreflect.MessageDescriptor) *Schema {
+	if schema := wellKnown(md); schema != nil {
+		return schema
+	}
+
+	name := string(md.FullName())
+	ref := &Schema{Ref: schemaPrefix + name}
+
+	if _, ok := g.doc.Components.Schemas[name]; ok {
+		return ref
+	}
+
+	schema := &Schema{
+		Type:        "object",
+		Description: g.comments(md),
+		Properties:  map[string]*Schema{},
+	}
+
+	// Register before walking the fields so recursive messages terminate.
+	g.doc.Components.Schemas[name] = schema
+
+	fields := md.Fields()
+	for idx := 0; idx < fields.Len(); idx++ {
+		fd := fields.Get(idx)
+		schema.Properties[fd.JSONName()] = g.fieldSchema(fd)
+
+		if validate.Rules(fd).GetRequired() {
+			schema.Required = append(schema.Required, fd.JSONName())
+		}
+	}
+
+	return ref
+}
+
+// fieldSchema describes fd, including its comment and constraints.
+func (g *generator) fieldSchema(fd protoreflect.FieldDescriptor) *Schema {
+	var schema *Schema
+
+	switch {
+	case fd.IsMap():
+		schema = &Schema{Type: "object", AdditionalProperties: g.valueSchema(fd.MapValue())}
+	case fd.IsList():
+		schema = &Schema{Type: "array", Items: g.valueSchema(fd)}
+	default:
+		schema = g.valueSchema(fd)
+		applyRules(schema, fd)
+	}
+
+	if desc := g.comments(fd); desc != "" {
+		// OpenAPI 3.0 ignores siblings of $ref, so wrap it to keep the
+		// description.
+		if schema.Ref != "" {
+			schema = &Schema{AllOf: []*Schema{schema}}
+		}
+
+		schema.Description = desc
+	}
+
+	return schema
+}
+
+// valueSchema describes a single value of fd, ignoring whether it is
+// repeated.
+func (g *generator) valueSchema(fd protoreflect.FieldDescriptor) *Schema {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return &Schema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &Schema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &Schema{Type: "integer", Format: "int64"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		// protojson writes 64 bit integers as strings.
+		return &Schema{Type: "string", Format: "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &Schema{Type: "string", Format: "uint64"}
+	case protoreflect.FloatKind:
+		return &Schema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &Schema{Type: "number", Format: "double"}
+	case protoreflect.StringKind:
+		return &Schema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &Schema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		schema := &Schema{Type: "string"}
+
+		values := fd.Enum().Values()
+		for idx := 0; idx < values.Len(); idx++ {
+			schema.Enum = append(schema.Enum, string(values.Get(idx).Name()))
+		}
+
+		return schema
+	default:
+		return g.messageRef(fd.Message())
+	}
+}
+
+// applyRules copies the (wab.validate.rules) constraints of fd that have an
+// OpenAPI equivalent onto schema.
+func applyRules(schema *Schema, fd protoreflect.FieldDescriptor) {
+	rules := validate.Rules(fd)
+	if rules == nil {
+		return
+	}
+
+	switch schema.Type {
+	case "integer", "number":
+		schema.Minimum = rules.Min
+		schema.Maximum = rules.Max
+	case "string":
+		if fd.Kind() == protoreflect.StringKind || fd.Kind() == protoreflect.BytesKind {
+			schema.MinLength = rules.MinLen
+			schema.MaxLength = rules.MaxLen
+			schema.Pattern = rules.GetPattern()
+		}
+	}
+}
+
+// wellKnown returns the inline schema of a well-known type with a special
+// JSON mapping, or nil for any other message.
+func wellKnown(md protoreflect.MessageDescriptor) *Schema {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return &Schema{Type: "string", Format: "date-time"}
+	case "google.protobuf.Duration":
+		return &Schema{Type: "string", Description: "A duration in seconds with an s suffix, like 1.5s."}
+	case "google.protobuf.FieldMask":
+		return &Schema{Type: "string", Description: "Comma separated field paths."}
+	case "google.protobuf.Empty":
+		return &Schema{Type: "object"}
+	case "google.protobuf.Struct":
+		return &Schema{Type: "object", AdditionalProperties: &Schema{}}
+	case "google.protobuf.Value":
+		return &Schema{}
+	case "google.protobuf.ListValue":
+		return &Schema{Type: "array", Items: &Schema{}}
+	case "google.protobuf.Any":
+		return &Schema{
+			Type:                 "object",
+			Properties:           map[string]*Schema{"@type": {Type: "string"}},
+			AdditionalProperties: &Schema{},
+		}
+	case "google.protobuf.BoolValue":
+		return &Schema{Type: "boolean"}
+	case "google.protobuf.StringValue":
+		return &Schema{Type: "string"}
+	case "google.protobuf.BytesValue":
+		return &Schema{Type: "string", Format: "byte"}
+	case "google.protobuf.Int32Value":
+		return &Schema{Type: "integer", Format: "int32"}
+	case "google.protobuf.UInt32Value":
+		return &Schema{Type: "integer", Format: "int64"}
+	case "google.protobuf.Int64Value":
+		return &Schema{Type: "string", Format: "int64"}
+	case "google.protobuf.UInt64Value":
+		return &Schema{Type: "string", Format: "uint64"}
+	case "google.protobuf.FloatValue":
+		return &Schema{Type: "number", Format: "float"}
+	case "google.protobuf.DoubleValue":
+		return &Schema{Type: "number", Format: "double"}
+	}
+
+	return nil
+}
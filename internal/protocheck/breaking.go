@@ -0,0 +1,250 @@
+package protocheck
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Severity says who a breaking change hurts.
+type Severity string
+
+const (
+	// Wire changes break clients and servers built from the old file, the
+	// bytes (or the method being called) no longer mean the same thing.
+	Wire Severity = "wire"
+	// Source changes keep the wire format but break code generated from the
+	// old file, and usually JSON clients too.
+	Source Severity = "source"
+)
+
+// Change is a single breaking change between two versions of a file.
+type Change struct {
+	Severity Severity
+	// Element is the full name of the element in the old file.
+	Element string
+	Message string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%-6s  %s: %s", c.Severity, c.Element, c.Message)
+}
+
+// Breaking lists the changes from older to newer that break existing clients:
+// removed or renumbered fields, changed types, removed methods and changed
+// streaming cardinality, among others. Additions are never breaking.
+func Breaking(older, newer protoreflect.FileDescriptor) []Change {
+	b := &breaking{oldPackage: older.Package(), newPackage: newer.Package()}
+
+	if older.Package() != newer.Package() {
+		b.add(Wire, protoreflect.FullName(older.Path()), "package changed from %q to %q, every full name changes", older.Package(), newer.Package())
+	}
+
+	b.messages(older.Messages(), newer.Messages())
+	b.enums(older.Enums(), newer.Enums())
+	b.services(older.Services(), newer.Services())
+
+	return b.changes
+}
+
+type breaking struct {
+	changes []Change
+
+	oldPackage protoreflect.FullName
+	newPackage protoreflect.FullName
+}
+
+// sameType compares the names of two referenced types relative to the
+// package of the compared files. Moving a file to a new package is reported
+// once, not again for every field and method using its messages.
+func (b *breaking) sameType(older, newer protoreflect.FullName) bool {
+	return relative(older, b.oldPackage) == relative(newer, b.newPackage)
+}
+
+func relative(name, pkg protoreflect.FullName) protoreflect.FullName {
+	if pkg != "" && strings.HasPrefix(string(name), string(pkg)+".") {
+		return name[len(pkg)+1:]
+	}
+
+	return name
+}
+
+func (b *breaking) add(severity Severity, element protoreflect.FullName, format string, args ...interface{}) {
+	b.changes = append(b.changes, Change{
+		Severity: severity,
+		Element:  string(element),
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (b *breaking) messages(older, newer protoreflect.MessageDescriptors) {
+	for idx := 0; idx < older.Len(); idx++ {
+		om := older.Get(idx)
+
+		nm := newer.ByName(om.Name())
+		if nm == nil {
+			b.add(Source, om.FullName(), "message removed")
+
+			continue
+		}
+
+		b.fields(om, nm)
+		b.messages(om.Messages(), nm.Messages())
+		b.enums(om.Enums(), nm.Enums())
+	}
+}
+
+func (b *breaking) fields(older, newer protoreflect.MessageDescriptor) {
+	fields := older.Fields()
+
+	for idx := 0; idx < fields.Len(); idx++ {
+		of := fields.Get(idx)
+
+		nf := newer.Fields().ByName(of.Name())
+		if nf == nil {
+			b.removedField(of, newer)
+
+			continue
+		}
+
+		if of.Number() != nf.Number() {
+			b.add(Wire, of.FullName(), "field number changed from %d to %d", of.Number(), nf.Number())
+		}
+
+		if of.Kind() != nf.Kind() || !b.sameType(typeName(of), typeName(nf)) {
+			b.add(Wire, of.FullName(), "type changed from %s to %s", fieldType(of), fieldType(nf))
+		} else if of.IsMap() != nf.IsMap() || of.IsList() != nf.IsList() {
+			b.add(Wire, of.FullName(), "label changed from %s to %s", label(of), label(nf))
+		}
+
+		if oneofName(of) != oneofName(nf) {
+			b.add(Source, of.FullName(), "moved from oneof %q to %q", oneofName(of), oneofName(nf))
+		}
+
+		if of.JSONName() != nf.JSONName() {
+			b.add(Source, of.FullName(), "JSON name changed from %q to %q", of.JSONName(), nf.JSONName())
+		}
+	}
+}
+
+// removedField reports a field that is gone by name. Keeping its number under
+// a new name is only a source change, dropping it without reserving the
+// number lets it be reused with a different meaning.
+func (b *breaking) removedField(of protoreflect.FieldDescriptor, newer protoreflect.MessageDescriptor) {
+	if nf := newer.Fields().ByNumber(of.Number()); nf != nil {
+		b.add(Source, of.FullName(), "renamed to %s", nf.Name())
+
+		if of.Kind() != nf.Kind() || !b.sameType(typeName(of), typeName(nf)) {
+			b.add(Wire, of.FullName(), "type changed from %s to %s", fieldType(of), fieldType(nf))
+		}
+
+		return
+	}
+
+	if newer.ReservedRanges().Has(of.Number()) {
+		b.add(Source, of.FullName(), "field removed, number %d is reserved", of.Number())
+
+		return
+	}
+
+	b.add(Wire, of.FullName(), "field removed without reserving number %d", of.Number())
+}
+
+func (b *breaking) enums(older, newer protoreflect.EnumDescriptors) {
+	for idx := 0; idx < older.Len(); idx++ {
+		oe := older.Get(idx)
+
+		ne := newer.ByName(oe.Name())
+		if ne == nil {
+			b.add(Source, oe.FullName(), "enum removed")
+
+			continue
+		}
+
+		values := oe.Values()
+		for idx := 0; idx < values.Len(); idx++ {
+			ov := values.Get(idx)
+
+			nv := ne.Values().ByName(ov.Name())
+
+			switch {
+			case nv == nil && ne.Values().ByNumber(ov.Number()) != nil:
+				b.add(Source, ov.FullName(), "renamed to %s", ne.Values().ByNumber(ov.Number()).Name())
+			case nv == nil:
+				b.add(Wire, ov.FullName(), "enum value %d removed", ov.Number())
+			case nv.Number() != ov.Number():
+				b.add(Wire, ov.FullName(), "number changed from %d to %d", ov.Number(), nv.Number())
+			}
+		}
+	}
+}
+
+func (b *breaking) services(older, newer protoreflect.ServiceDescriptors) {
+	for idx := 0; idx < older.Len(); idx++ {
+		oldSvc := older.Get(idx)
+
+		newSvc := newer.ByName(oldSvc.Name())
+		if newSvc == nil {
+			b.add(Wire, oldSvc.FullName(), "service removed")
+
+			continue
+		}
+
+		methods := oldSvc.Methods()
+		for idx := 0; idx < methods.Len(); idx++ {
+			om := methods.Get(idx)
+
+			nm := newSvc.Methods().ByName(om.Name())
+			if nm == nil {
+				b.add(Wire, om.FullName(), "method removed")
+
+				continue
+			}
+
+			if !b.sameType(om.Input().FullName(), nm.Input().FullName()) {
+				b.add(Wire, om.FullName(), "request type changed from %s to %s", om.Input().FullName(), nm.Input().FullName())
+			}
+
+			if !b.sameType(om.Output().FullName(), nm.Output().FullName()) {
+				b.add(Wire, om.FullName(), "response type changed from %s to %s", om.Output().FullName(), nm.Output().FullName())
+			}
+
+			if om.IsStreamingClient() != nm.IsStreamingClient() || om.IsStreamingServer() != nm.IsStreamingServer() {
+				b.add(Wire, om.FullName(), "changed from %s to %s", cardinality(om), cardinality(nm))
+			}
+		}
+	}
+}
+
+func fieldType(fd protoreflect.FieldDescriptor) string {
+	if name := typeName(fd); name != "" {
+		return string(name)
+	}
+
+	return fd.Kind().String()
+}
+
+func label(fd protoreflect.FieldDescriptor) string {
+	switch {
+	case fd.IsMap():
+		return "map"
+	case fd.IsList():
+		return "repeated"
+	}
+
+	return "singular"
+}
+
+func cardinality(md protoreflect.MethodDescriptor) string {
+	switch {
+	case md.IsStreamingClient() && md.IsStreamingServer():
+		return "bidi streaming"
+	case md.IsStreamingClient():
+		return "client streaming"
+	case md.IsStreamingServer():
+		return "server streaming"
+	}
+
+	return "unary"
+}
@@ -0,0 +1,196 @@
+// Package protocheck compares proto descriptors. Drift finds any structural
+// difference between two versions of the same file, which is how WAB notices
+// the embedded .proto source and the generated Go code disagree. Breaking
+// only looks for changes that hurt existing clients, for gating API changes.
+package protocheck
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Mismatch is the first element found to differ between two descriptors.
+type Mismatch struct {
+	// Element is the full name of the differing element, like
+	// "wab.greeter.v1.HelloRequest.name".
+	Element string
+	// Reason says what differs, in terms of the "want" and "got" files.
+	Reason string
+}
+
+func (m *Mismatch) Error() string {
+	return fmt.Sprintf("%s: %s", m.Element, m.Reason)
+}
+
+// Drift compares the messages, fields, enums and services of want and got
+// and returns the first difference, or nil if they describe the same API.
+// Options and comments are ignored. wantName and gotName label the two sides
+// in the reason, for example "greeter.proto" and "gen/greeterpb".
+func Drift(want, got protoreflect.FileDescriptor, wantName, gotName string) *Mismatch {
+	d := &drift{wantName: wantName, gotName: gotName}
+
+	if want.Package() != got.Package() {
+		return d.differ(want.Path(), "package", want.Package(), got.Package())
+	}
+
+	if m := d.messages(want.Messages(), got.Messages()); m != nil {
+		return m
+	}
+
+	if m := d.enums(want.Enums(), got.Enums()); m != nil {
+		return m
+	}
+
+	return d.services(want.Services(), got.Services())
+}
+
+type drift struct {
+	wantName string
+	gotName  string
+}
+
+func (d *drift) differ(element, what string, want, got interface{}) *Mismatch {
+	return &Mismatch{
+		Element: element,
+		Reason:  fmt.Sprintf("%s is %v in %s but %v in %s", what, want, d.wantName, got, d.gotName),
+	}
+}
+
+func (d *drift) missing(element string, inWant bool) *Mismatch {
+	where, notWhere := d.wantName, d.gotName
+	if !inWant {
+		where, notWhere = notWhere, where
+	}
+
+	return &Mismatch{
+		Element: element,
+		Reason:  fmt.Sprintf("declared in %s but missing from %s", where, notWhere),
+	}
+}
+
+// descriptorList is the common part of the protoreflect list types.
+type descriptorList[T protoreflect.Descriptor] interface {
+	Len() int
+	Get(i int) T
+	ByName(name protoreflect.Name) T
+}
+
+// pair calls check for every element of want with the element of the same
+// name in got, then reports anything only got declares.
+func pair[T protoreflect.Descriptor](d *drift, want, got descriptorList[T], check func(w, g T) *Mismatch) *Mismatch {
+	for idx := 0; idx < want.Len(); idx++ {
+		w := want.Get(idx)
+
+		g := got.ByName(w.Name())
+		if isNil(g) {
+			return d.missing(string(w.FullName()), true)
+		}
+
+		if m := check(w, g); m != nil {
+			return m
+		}
+	}
+
+	for idx := 0; idx < got.Len(); idx++ {
+		if g := got.Get(idx); isNil(want.ByName(g.Name())) {
+			return d.missing(string(g.FullName()), false)
+		}
+	}
+
+	return nil
+}
+
+// isNil reports whether a ByName lookup came back empty.
+func isNil[T protoreflect.Descriptor](d T) bool {
+	return any(d) == nil
+}
+
+func (d *drift) messages(want, got protoreflect.MessageDescriptors) *Mismatch {
+	return pair[protoreflect.MessageDescriptor](d, want, got, func(w, g protoreflect.MessageDescriptor) *Mismatch {
+		if m := d.fields(w.Fields(), g.Fields()); m != nil {
+			return m
+		}
+
+		if m := d.messages(w.Messages(), g.Messages()); m != nil {
+			return m
+		}
+
+		return d.enums(w.Enums(), g.Enums())
+	})
+}
+
+func (d *drift) fields(want, got protoreflect.FieldDescriptors) *Mismatch {
+	return pair[protoreflect.FieldDescriptor](d, want, got, func(w, g protoreflect.FieldDescriptor) *Mismatch {
+		name := string(w.FullName())
+
+		switch {
+		case w.Number() != g.Number():
+			return d.differ(name, "number", w.Number(), g.Number())
+		case w.Kind() != g.Kind():
+			return d.differ(name, "type", w.Kind(), g.Kind())
+		case w.Cardinality() != g.Cardinality():
+			return d.differ(name, "label", w.Cardinality(), g.Cardinality())
+		case typeName(w) != typeName(g):
+			return d.differ(name, "type", typeName(w), typeName(g))
+		case oneofName(w) != oneofName(g):
+			return d.differ(name, "oneof", oneofName(w), oneofName(g))
+		}
+
+		return nil
+	})
+}
+
+func (d *drift) enums(want, got protoreflect.EnumDescriptors) *Mismatch {
+	return pair[protoreflect.EnumDescriptor](d, want, got, func(w, g protoreflect.EnumDescriptor) *Mismatch {
+		return pair[protoreflect.EnumValueDescriptor](d, w.Values(), g.Values(), func(w, g protoreflect.EnumValueDescriptor) *Mismatch {
+			if w.Number() != g.Number() {
+				return d.differ(string(w.FullName()), "number", w.Number(), g.Number())
+			}
+
+			return nil
+		})
+	})
+}
+
+func (d *drift) services(want, got protoreflect.ServiceDescriptors) *Mismatch {
+	return pair[protoreflect.ServiceDescriptor](d, want, got, func(w, g protoreflect.ServiceDescriptor) *Mismatch {
+		return pair[protoreflect.MethodDescriptor](d, w.Methods(), g.Methods(), func(w, g protoreflect.MethodDescriptor) *Mismatch {
+			name := string(w.FullName())
+
+			switch {
+			case w.Input().FullName() != g.Input().FullName():
+				return d.differ(name, "request type", w.Input().FullName(), g.Input().FullName())
+			case w.Output().FullName() != g.Output().FullName():
+				return d.differ(name, "response type", w.Output().FullName(), g.Output().FullName())
+			case w.IsStreamingClient() != g.IsStreamingClient():
+				return d.differ(name, "client streaming", w.IsStreamingClient(), g.IsStreamingClient())
+			case w.IsStreamingServer() != g.IsStreamingServer():
+				return d.differ(name, "server streaming", w.IsStreamingServer(), g.IsStreamingServer())
+			}
+
+			return nil
+		})
+	})
+}
+
+// typeName is the full name of the message or enum a field refers to, empty
+// for scalars.
+func typeName(fd protoreflect.FieldDescriptor) protoreflect.FullName {
+	switch {
+	case fd.Message() != nil:
+		return fd.Message().FullName()
+	case fd.Enum() != nil:
+		return fd.Enum().FullName()
+	}
+
+	return ""
+}
+
+func oneofName(fd protoreflect.FieldDescriptor) protoreflect.Name {
+	if oneof := fd.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+		return oneof.Name()
+	}
+
+	return ""
+}
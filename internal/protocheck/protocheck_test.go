@@ -0,0 +1,274 @@
+package protocheck
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// baseProto is the "older" file of every test case.
+const baseProto = `syntax = "proto3";
+package test.v1;
+
+message Request {
+  string name = 1;
+  uint32 qty = 2;
+  reserved 9;
+  oneof pick {
+    string a = 3;
+    string b = 4;
+  }
+}
+
+message Reply {
+  string message = 1;
+  Mood mood = 2;
+}
+
+enum Mood {
+  MOOD_UNSPECIFIED = 0;
+  MOOD_HAPPY = 1;
+}
+
+service Greeter {
+  rpc Greet(Request) returns (Reply);
+  rpc GreetMany(Request) returns (stream Reply);
+}
+`
+
+func parse(t *testing.T, source string) protoreflect.FileDescriptor {
+	t.Helper()
+
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(map[string]string{"test.proto": source}),
+	}
+
+	parsed, err := parser.ParseFiles("test.proto")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+
+	files, err := protodesc.NewFiles(desc.ToFileDescriptorSet(parsed...))
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+
+	fd, err := files.FindFileByPath("test.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fd
+}
+
+// edit applies old, new pairs to baseProto, failing when an old string isn't
+// there so a case can't silently test nothing.
+func edit(t *testing.T, pairs ...string) string {
+	t.Helper()
+
+	source := baseProto
+
+	for idx := 0; idx < len(pairs); idx += 2 {
+		replaced := strings.Replace(source, pairs[idx], pairs[idx+1], 1)
+		if replaced == source {
+			t.Fatalf("%q isn't in the base proto", pairs[idx])
+		}
+
+		source = replaced
+	}
+
+	return source
+}
+
+func TestBreaking(t *testing.T) {
+	cases := []struct {
+		name    string
+		edits   []string
+		changes []Change
+	}{
+		{
+			name: "unchanged",
+		},
+		{
+			name:  "added field and method",
+			edits: []string{"  uint32 qty = 2;\n", "  uint32 qty = 2;\n  string extra = 5;\n", "service Greeter {\n", "service Greeter {\n  rpc Other(Request) returns (Reply);\n"},
+		},
+		{
+			name:  "comments don't matter",
+			edits: []string{"message Request {", "// Request is new.\nmessage Request {"},
+		},
+		{
+			name:    "renumbered field",
+			edits:   []string{"uint32 qty = 2;", "uint32 qty = 5;"},
+			changes: []Change{{Wire, "test.v1.Request.qty", "field number changed from 2 to 5"}},
+		},
+		{
+			name:    "changed type",
+			edits:   []string{"uint32 qty = 2;", "int64 qty = 2;"},
+			changes: []Change{{Wire, "test.v1.Request.qty", "type changed from uint32 to int64"}},
+		},
+		{
+			name:    "made repeated",
+			edits:   []string{"uint32 qty = 2;", "repeated uint32 qty = 2;"},
+			changes: []Change{{Wire, "test.v1.Request.qty", "label changed from singular to repeated"}},
+		},
+		{
+			name:    "renamed field",
+			edits:   []string{"uint32 qty = 2;", "uint32 count = 2;"},
+			changes: []Change{{Source, "test.v1.Request.qty", "renamed to count"}},
+		},
+		{
+			name:    "removed field",
+			edits:   []string{"  uint32 qty = 2;\n", ""},
+			changes: []Change{{Wire, "test.v1.Request.qty", "field removed without reserving number 2"}},
+		},
+		{
+			name:    "removed and reserved field",
+			edits:   []string{"  uint32 qty = 2;\n", "", "reserved 9;", "reserved 2, 9;"},
+			changes: []Change{{Source, "test.v1.Request.qty", "field removed, number 2 is reserved"}},
+		},
+		{
+			name:    "moved out of a oneof",
+			edits:   []string{"    string b = 4;\n  }\n", "  }\n  string b = 4;\n"},
+			changes: []Change{{Source, "test.v1.Request.b", `moved from oneof "pick" to ""`}},
+		},
+		{
+			name:    "changed JSON name",
+			edits:   []string{"string message = 1;", `string message = 1 [json_name = "msg"];`},
+			changes: []Change{{Source, "test.v1.Reply.message", `JSON name changed from "message" to "msg"`}},
+		},
+		{
+			// Enum values are scoped to the enum's parent, like in C++.
+			name:    "removed enum value",
+			edits:   []string{"  MOOD_HAPPY = 1;\n", ""},
+			changes: []Change{{Wire, "test.v1.MOOD_HAPPY", "enum value 1 removed"}},
+		},
+		{
+			name:    "renamed enum value",
+			edits:   []string{"MOOD_HAPPY = 1;", "MOOD_GLAD = 1;"},
+			changes: []Change{{Source, "test.v1.MOOD_HAPPY", "renamed to MOOD_GLAD"}},
+		},
+		{
+			name:    "removed method",
+			edits:   []string{"  rpc GreetMany(Request) returns (stream Reply);\n", ""},
+			changes: []Change{{Wire, "test.v1.Greeter.GreetMany", "method removed"}},
+		},
+		{
+			name:    "changed streaming",
+			edits:   []string{"returns (stream Reply)", "returns (Reply)"},
+			changes: []Change{{Wire, "test.v1.Greeter.GreetMany", "changed from server streaming to unary"}},
+		},
+		{
+			name:    "changed response type",
+			edits:   []string{"rpc Greet(Request) returns (Reply);", "rpc Greet(Request) returns (Request);"},
+			changes: []Change{{Wire, "test.v1.Greeter.Greet", "response type changed from test.v1.Reply to test.v1.Request"}},
+		},
+		{
+			name:    "removed service",
+			edits:   []string{"service Greeter {", "service Welcomer {"},
+			changes: []Change{{Wire, "test.v1.Greeter", "service removed"}},
+		},
+		{
+			// Every type reference changes with the package, that's reported
+			// once rather than for each field and method.
+			name:    "changed package",
+			edits:   []string{"package test.v1;", "package test.v2;"},
+			changes: []Change{{Wire, "test.proto", `package changed from "test.v1" to "test.v2", every full name changes`}},
+		},
+	}
+
+	older := parse(t, baseProto)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			newer := parse(t, edit(t, tc.edits...))
+
+			got := Breaking(older, newer)
+			if !reflect.DeepEqual(got, tc.changes) {
+				t.Errorf("got changes %v, want %v", got, tc.changes)
+			}
+		})
+	}
+}
+
+func TestDrift(t *testing.T) {
+	cases := []struct {
+		name    string
+		edits   []string
+		element string
+		reason  string
+	}{
+		{
+			name: "same",
+		},
+		{
+			name:  "comments and options are ignored",
+			edits: []string{"string message = 1;", "// The greeting.\n  string message = 1 [deprecated = true];"},
+		},
+		{
+			name:    "package",
+			edits:   []string{"package test.v1;", "package test.v2;"},
+			element: "test.proto",
+			reason:  "package is test.v1 in source but test.v2 in gen",
+		},
+		{
+			name:    "field number",
+			edits:   []string{"uint32 qty = 2;", "uint32 qty = 5;"},
+			element: "test.v1.Request.qty",
+			reason:  "number is 2 in source but 5 in gen",
+		},
+		{
+			name:    "field type",
+			edits:   []string{"Mood mood = 2;", "Request mood = 2;"},
+			element: "test.v1.Reply.mood",
+			reason:  "type is enum in source but message in gen",
+		},
+		{
+			name:    "field only in gen",
+			edits:   []string{"  uint32 qty = 2;\n", "  uint32 qty = 2;\n  string extra = 5;\n"},
+			element: "test.v1.Request.extra",
+			reason:  "declared in gen but missing from source",
+		},
+		{
+			name:    "enum value only in source",
+			edits:   []string{"  MOOD_HAPPY = 1;\n", ""},
+			element: "test.v1.MOOD_HAPPY",
+			reason:  "declared in source but missing from gen",
+		},
+		{
+			name:    "streaming",
+			edits:   []string{"returns (stream Reply)", "returns (Reply)"},
+			element: "test.v1.Greeter.GreetMany",
+			reason:  "server streaming is true in source but false in gen",
+		},
+	}
+
+	want := parse(t, baseProto)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Drift(want, parse(t, edit(t, tc.edits...)), "source", "gen")
+
+			if tc.element == "" {
+				if got != nil {
+					t.Fatalf("expected no drift, got %v", got)
+				}
+
+				return
+			}
+
+			if got == nil {
+				t.Fatal("expected drift, got none")
+			}
+
+			if got.Element != tc.element || got.Reason != tc.reason {
+				t.Errorf("got %s: %s, want %s: %s", got.Element, got.Reason, tc.element, tc.reason)
+			}
+		})
+	}
+}
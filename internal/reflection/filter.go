@@ -0,0 +1,86 @@
+package reflection
+
+import (
+	"fmt"
+	"path"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Filter decides which services and files are revealed. Patterns use
+// path.Match syntax, where "*" matches any run of characters without a slash,
+// so "wab.admin.*" covers every service in the wab.admin packages and
+// "admin/*.proto" every file in the admin directory. The zero Filter reveals
+// everything.
+type Filter struct {
+	// Allow lists the services to reveal, all of them when empty.
+	Allow []string
+	// Deny lists services and files to hide, it wins over Allow.
+	Deny []string
+}
+
+// Validate reports the first malformed pattern.
+func (f Filter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Allow...), f.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("bad pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// Service reports whether the service called name is revealed.
+func (f Filter) Service(name string) bool {
+	if len(f.Allow) > 0 && !match(f.Allow, name) {
+		return false
+	}
+
+	return !match(f.Deny, name)
+}
+
+// File reports whether fd is revealed. Files declaring a hidden service are
+// hidden as well, otherwise a symbol lookup on any of their messages would
+// give the service away.
+func (f Filter) File(fd protoreflect.FileDescriptor) bool {
+	if match(f.Deny, fd.Path()) {
+		return false
+	}
+
+	services := fd.Services()
+	for idx := 0; idx < services.Len(); idx++ {
+		if !f.Service(string(services.Get(idx).FullName())) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Reveals reports whether sd and the file declaring it are both revealed.
+func (f Filter) Reveals(sd protoreflect.ServiceDescriptor) bool {
+	return f.Service(string(sd.FullName())) && f.File(sd.ParentFile())
+}
+
+// Services keeps the services the filter reveals, in order.
+func (f Filter) Services(services []protoreflect.ServiceDescriptor) []protoreflect.ServiceDescriptor {
+	var revealed []protoreflect.ServiceDescriptor
+
+	for _, sd := range services {
+		if f.Reveals(sd) {
+			revealed = append(revealed, sd)
+		}
+	}
+
+	return revealed
+}
+
+func match(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,124 @@
+// Package reflection serves gRPC server reflection, both the grpc.reflection.v1
+// API and the legacy v1alpha one older tools still use, and lets a Filter
+// decide which services and files it reveals.
+package reflection
+
+import (
+	"google.golang.org/grpc"
+	grpcreflection "google.golang.org/grpc/reflection"
+	v1alphapb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Register serves both reflection APIs on registrar. services lists what can
+// be called, resolver is where the descriptors come from. Only what filter
+// reveals can be listed or looked up, the files a revealed file imports are
+// still sent along with it since clients can't use it without them.
+func Register(registrar grpc.ServiceRegistrar, services grpcreflection.ServiceInfoProvider, resolver protodesc.Resolver, filter Filter) {
+	alpha := grpcreflection.NewServer(grpcreflection.ServerOptions{
+		Services:           filteredServices{services, filter},
+		DescriptorResolver: filteredResolver{resolver, filter},
+		ExtensionResolver:  filteredExtensions{filter},
+	})
+
+	v1alphapb.RegisterServerReflectionServer(registrar, alpha)
+	registrar.RegisterService(&v1ServiceDesc, alpha)
+}
+
+type filteredServices struct {
+	grpcreflection.ServiceInfoProvider
+	filter Filter
+}
+
+func (s filteredServices) GetServiceInfo() map[string]grpc.ServiceInfo {
+	services := map[string]grpc.ServiceInfo{}
+
+	for name, info := range s.ServiceInfoProvider.GetServiceInfo() {
+		if s.filter.Service(name) {
+			services[name] = info
+		}
+	}
+
+	return services
+}
+
+type filteredResolver struct {
+	resolver protodesc.Resolver
+	filter   Filter
+}
+
+func (r filteredResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	fd, err := r.resolver.FindFileByPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.filter.File(fd) {
+		return nil, protoregistry.NotFound
+	}
+
+	return fd, nil
+}
+
+func (r filteredResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	d, err := r.resolver.FindDescriptorByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.filter.File(d.ParentFile()) {
+		return nil, protoregistry.NotFound
+	}
+
+	return d, nil
+}
+
+// filteredExtensions hides extensions declared in hidden files, the
+// reflection server would otherwise send their file for an extension lookup.
+type filteredExtensions struct {
+	filter Filter
+}
+
+func (e filteredExtensions) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	return e.check(protoregistry.GlobalTypes.FindExtensionByName(field))
+}
+
+func (e filteredExtensions) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	return e.check(protoregistry.GlobalTypes.FindExtensionByNumber(message, field))
+}
+
+func (e filteredExtensions) RangeExtensionsByMessage(message protoreflect.FullName, f func(protoreflect.ExtensionType) bool) {
+	protoregistry.GlobalTypes.RangeExtensionsByMessage(message, func(xt protoreflect.ExtensionType) bool {
+		if !e.filter.File(xt.TypeDescriptor().ParentFile()) {
+			return true
+		}
+
+		return f(xt)
+	})
+}
+
+func (e filteredExtensions) check(xt protoreflect.ExtensionType, err error) (protoreflect.ExtensionType, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	if !e.filter.File(xt.TypeDescriptor().ParentFile()) {
+		return nil, protoregistry.NotFound
+	}
+
+	return xt, nil
+}
+
+// v1ServiceDesc serves grpc.reflection.v1 with the v1alpha handler. The two
+// APIs only differ in their package, their messages are the same on the wire.
+// The generated grpc_reflection_v1 package can't be linked in, grpcui's
+// reflection client registers its own copy of the same proto file.
+var v1ServiceDesc = func() grpc.ServiceDesc {
+	desc := v1alphapb.ServerReflection_ServiceDesc
+	desc.ServiceName = "grpc.reflection.v1.ServerReflection"
+	desc.Metadata = "grpc/reflection/v1/reflection.proto"
+
+	return desc
+}()
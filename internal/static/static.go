@@ -0,0 +1,264 @@
+// Package static serves the embedded UI assets. Every file is loaded once:
+// its strong ETag comes from a hash of its content, and gzip and brotli
+// variants are picked by Accept-Encoding. The variants come from the build
+// (file.js.gz and file.js.br next to file.js), gzip is compressed at load time
+// when the build didn't provide one. Hashed Vite assets are cached forever,
+// everything else is revalidated.
+package static
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// CacheImmutable is for files whose name changes with their content.
+	CacheImmutable = "public, max-age=31536000, immutable"
+	// CacheRevalidate makes browsers check the ETag before every reuse.
+	CacheRevalidate = "no-cache"
+
+	// minCompressSize is the size below which compressing isn't worth it.
+	minCompressSize = 1024
+)
+
+// hashedName matches the file names Vite gives bundled assets, like
+// index-4f1c9a7b.js.
+var hashedName = regexp.MustCompile(`-[A-Za-z0-9_-]{8,}\.[a-z0-9]+$`)
+
+// compressible lists the types worth compressing, images and fonts already
+// are.
+var compressible = []string{"text/", "application/javascript", "application/json", "image/svg+xml", "application/wasm"}
+
+// encodings are the supported Content-Encodings, most preferred first.
+var encodings = []struct {
+	name   string
+	suffix string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// Handler serves the files of a fs.FS.
+type Handler struct {
+	files map[string]*file
+}
+
+type file struct {
+	contentType string
+	etag        string
+	cache       string
+	identity    []byte
+	// variants holds the compressed versions by Content-Encoding.
+	variants map[string][]byte
+}
+
+// New loads every file of fsys. Build-time .gz and .br files are served as
+// variants of the file they compress, not on their own.
+func New(fsys fs.FS) (*Handler, error) {
+	h := &Handler{files: map[string]*file{}}
+
+	contents := map[string][]byte{}
+
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		contents[name] = data
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading static files: %w", err)
+	}
+
+	for name, data := range contents {
+		if variantOf(name, contents) != "" {
+			continue
+		}
+
+		f := &file{
+			contentType: contentType(name, data),
+			etag:        ETag(data),
+			cache:       CacheRevalidate,
+			identity:    data,
+			variants:    map[string][]byte{},
+		}
+
+		if strings.HasPrefix(name, "assets/") && hashedName.MatchString(name) {
+			f.cache = CacheImmutable
+		}
+
+		for _, enc := range encodings {
+			if compressed, ok := contents[name+enc.suffix]; ok {
+				f.variants[enc.name] = compressed
+			}
+		}
+
+		if _, ok := f.variants["gzip"]; !ok && shouldCompress(f.contentType, data) {
+			if compressed, err := gzipBytes(data); err == nil && len(compressed) < len(data) {
+				f.variants["gzip"] = compressed
+			}
+		}
+
+		h.files[name] = f
+	}
+
+	return h, nil
+}
+
+// variantOf returns the file name is a compressed variant of, if that file
+// exists.
+func variantOf(name string, contents map[string][]byte) string {
+	for _, enc := range encodings {
+		if base, ok := strings.CutSuffix(name, enc.suffix); ok {
+			if _, exists := contents[base]; exists {
+				return base
+			}
+		}
+	}
+
+	return ""
+}
+
+// Has reports whether the file at urlPath exists.
+func (h *Handler) Has(urlPath string) bool {
+	_, ok := h.files[strings.TrimPrefix(path.Clean("/"+urlPath), "/")]
+
+	return ok
+}
+
+func (h *Handler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	f, ok := h.files[strings.TrimPrefix(path.Clean("/"+req.URL.Path), "/")]
+	if !ok {
+		http.NotFound(resp, req)
+		return
+	}
+
+	data, encoding := f.identity, ""
+	for _, enc := range encodings {
+		if variant, ok := f.variants[enc.name]; ok && accepts(req, enc.name) {
+			data, encoding = variant, enc.name
+			break
+		}
+	}
+
+	header := resp.Header()
+	header.Set("Content-Type", f.contentType)
+	header.Set("Cache-Control", f.cache)
+
+	if len(f.variants) > 0 {
+		header.Add("Vary", "Accept-Encoding")
+	}
+
+	// Every representation needs its own strong ETag.
+	if encoding != "" {
+		header.Set("Content-Encoding", encoding)
+		header.Set("ETag", strings.TrimSuffix(f.etag, `"`)+"-"+encoding+`"`)
+	} else {
+		header.Set("ETag", f.etag)
+	}
+
+	// ServeContent handles If-None-Match, Range and HEAD.
+	http.ServeContent(resp, req, "", time.Time{}, bytes.NewReader(data))
+}
+
+// ETag is a strong, quoted ETag for content made of parts.
+func ETag(parts ...[]byte) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write(part)
+	}
+
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// IfNoneMatch reports whether the If-None-Match header of req matches etag,
+// meaning the client's copy is current.
+func IfNoneMatch(req *http.Request, etag string) bool {
+	for _, candidate := range strings.Split(req.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// accepts reports whether req accepts the encoding, honouring q=0.
+func accepts(req *http.Request, encoding string) bool {
+	for _, part := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if value, err := strconv.ParseFloat(q, 64); err == nil && value == 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func contentType(name string, data []byte) string {
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		return ct
+	}
+
+	return http.DetectContentType(data)
+}
+
+func shouldCompress(contentType string, data []byte) bool {
+	if len(data) < minCompressSize {
+		return false
+	}
+
+	for _, prefix := range compressible {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writer, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,185 @@
+package static
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// bigJS is large enough to be compressed at load time.
+var bigJS = []byte("console.log('" + strings.Repeat("wab ", 1024) + "');\n")
+
+func testHandler(t *testing.T) *Handler {
+	t.Helper()
+
+	handler, err := New(fstest.MapFS{
+		"index.html":                 {Data: []byte("<html></html>")},
+		"favicon.ico":                {Data: []byte{0, 0, 1, 0}},
+		"assets/index-4f1c9a7b.js":   {Data: bigJS},
+		"assets/app-0123abcd.css":    {Data: []byte("body{}")},
+		"assets/app-0123abcd.css.br": {Data: []byte("brotli")},
+		"assets/app-0123abcd.css.gz": {Data: []byte("gzipped")},
+		"logo.svg.gz":                {Data: []byte("orphan")},
+	})
+	if err != nil {
+		t.Fatalf("failed to load the files: %v", err)
+	}
+
+	return handler
+}
+
+func serve(handler *Handler, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	return resp
+}
+
+func TestEncodingNegotiation(t *testing.T) {
+	handler := testHandler(t)
+
+	cases := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		encoding       string
+		body           string
+	}{
+		{name: "no Accept-Encoding", path: "/assets/app-0123abcd.css", body: "body{}"},
+		{name: "brotli preferred", path: "/assets/app-0123abcd.css", acceptEncoding: "gzip, deflate, br", encoding: "br", body: "brotli"},
+		{name: "gzip only", path: "/assets/app-0123abcd.css", acceptEncoding: "gzip", encoding: "gzip", body: "gzipped"},
+		{name: "brotli refused", path: "/assets/app-0123abcd.css", acceptEncoding: "br;q=0, gzip", encoding: "gzip", body: "gzipped"},
+		{name: "gzip at load time", path: "/assets/index-4f1c9a7b.js", acceptEncoding: "br, gzip", encoding: "gzip"},
+		{name: "small files stay identity", path: "/index.html", acceptEncoding: "gzip", body: "<html></html>"},
+		{name: "orphan variant is a file", path: "/logo.svg.gz", acceptEncoding: "gzip", body: "orphan"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serve(handler, tc.path, http.Header{"Accept-Encoding": {tc.acceptEncoding}})
+
+			if resp.Code != http.StatusOK {
+				t.Fatalf("got status %d", resp.Code)
+			}
+
+			if got := resp.Header().Get("Content-Encoding"); got != tc.encoding {
+				t.Errorf("got Content-Encoding %q, want %q", got, tc.encoding)
+			}
+
+			body := resp.Body.Bytes()
+
+			if tc.encoding == "gzip" && tc.body == "" {
+				reader, err := gzip.NewReader(bytes.NewReader(body))
+				if err != nil {
+					t.Fatalf("body isn't gzip: %v", err)
+				}
+
+				if body, err = io.ReadAll(reader); err != nil {
+					t.Fatalf("body isn't gzip: %v", err)
+				}
+
+				if !bytes.Equal(body, bigJS) {
+					t.Errorf("gzip body doesn't decompress to the file")
+				}
+
+				return
+			}
+
+			if string(body) != tc.body {
+				t.Errorf("got body %q, want %q", body, tc.body)
+			}
+		})
+	}
+}
+
+func TestETags(t *testing.T) {
+	handler := testHandler(t)
+
+	identity := serve(handler, "/assets/app-0123abcd.css", nil).Header().Get("ETag")
+	brotli := serve(handler, "/assets/app-0123abcd.css", http.Header{"Accept-Encoding": {"br"}}).Header().Get("ETag")
+	gzipped := serve(handler, "/assets/app-0123abcd.css", http.Header{"Accept-Encoding": {"gzip"}}).Header().Get("ETag")
+
+	if identity != ETag([]byte("body{}")) {
+		t.Errorf("got ETag %s, want the content hash %s", identity, ETag([]byte("body{}")))
+	}
+
+	// Every representation has its own strong ETag.
+	if identity == brotli || identity == gzipped || brotli == gzipped {
+		t.Errorf("representations share ETags: %s %s %s", identity, brotli, gzipped)
+	}
+
+	for _, etag := range []string{identity, brotli, gzipped} {
+		if !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+			t.Errorf("ETag %s isn't a quoted strong ETag", etag)
+		}
+	}
+
+	cases := []struct {
+		name           string
+		ifNoneMatch    string
+		acceptEncoding string
+		status         int
+	}{
+		{name: "current", ifNoneMatch: identity, status: http.StatusNotModified},
+		{name: "weak comparison", ifNoneMatch: "W/" + identity, status: http.StatusNotModified},
+		{name: "one of a list", ifNoneMatch: `"stale", ` + gzipped, acceptEncoding: "gzip", status: http.StatusNotModified},
+		{name: "other representation", ifNoneMatch: identity, acceptEncoding: "gzip", status: http.StatusOK},
+		{name: "stale", ifNoneMatch: `"stale"`, status: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := serve(handler, "/assets/app-0123abcd.css", http.Header{
+				"If-None-Match":   {tc.ifNoneMatch},
+				"Accept-Encoding": {tc.acceptEncoding},
+			})
+
+			if resp.Code != tc.status {
+				t.Errorf("got status %d, want %d", resp.Code, tc.status)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("If-None-Match", tc.ifNoneMatch)
+
+			if tc.acceptEncoding == "" && IfNoneMatch(req, identity) != (tc.status == http.StatusNotModified) {
+				t.Errorf("IfNoneMatch disagrees with the handler")
+			}
+		})
+	}
+}
+
+func TestCachePolicy(t *testing.T) {
+	handler := testHandler(t)
+
+	cases := map[string]string{
+		"/assets/index-4f1c9a7b.js": CacheImmutable,
+		"/assets/app-0123abcd.css":  CacheImmutable,
+		"/index.html":               CacheRevalidate,
+		"/favicon.ico":              CacheRevalidate,
+	}
+
+	for path, want := range cases {
+		resp := serve(handler, path, nil)
+		if got := resp.Header().Get("Cache-Control"); got != want {
+			t.Errorf("%s: got Cache-Control %q, want %q", path, got, want)
+		}
+	}
+
+	if resp := serve(handler, "/missing.js", nil); resp.Code != http.StatusNotFound {
+		t.Errorf("missing file: got status %d", resp.Code)
+	}
+
+	if !handler.Has("assets/../index.html") || handler.Has("assets/app-0123abcd.css.br") {
+		t.Errorf("Has should clean the path and hide variants")
+	}
+}
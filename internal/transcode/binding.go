@@ -0,0 +1,211 @@
+package transcode
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Binding is a single google.api.http rule resolved against the method it was
+// declared on. A method can have several bindings through
+// additional_bindings.
+type Binding struct {
+	// Verb is the HTTP method, like "GET" or "POST".
+	Verb string
+	// Template is the path template exactly as written in the proto file, for
+	// example "/api/v1/greet/{name}".
+	Template string
+	// Body is the request field mapped to the HTTP body, "*" for the whole
+	// message or empty for no body.
+	Body string
+	// ResponseBody is the response field sent as the HTTP body, empty for the
+	// whole message.
+	ResponseBody string
+	// Method is the gRPC method the binding dispatches to.
+	Method protoreflect.MethodDescriptor
+	// PathParams are the request fields (dotted paths) captured by the path
+	// template, in the order they appear.
+	PathParams []string
+
+	echoPath string
+	input    protoreflect.MessageType
+	output   protoreflect.MessageType
+}
+
+// FullMethod is the gRPC method name used to invoke the binding, like
+// "/wab.greeter.v1.Greeter/Greet".
+func (b *Binding) FullMethod() string {
+	return fmt.Sprintf("/%s/%s", b.Method.Parent().FullName(), b.Method.Name())
+}
+
+// ServerStreaming is true when responses are sent as newline delimited JSON.
+func (b *Binding) ServerStreaming() bool {
+	return b.Method.IsStreamingServer()
+}
+
+// bindingsFor returns every binding declared on md. Methods without a
+// google.api.http option return nothing.
+func bindingsFor(md protoreflect.MethodDescriptor) ([]*Binding, error) {
+	opts, ok := md.Options().(*descriptorpb.MethodOptions)
+	if !ok || opts == nil || !proto.HasExtension(opts, annotations.E_Http) {
+		return nil, nil
+	}
+
+	rule, _ := proto.GetExtension(opts, annotations.E_Http).(*annotations.HttpRule)
+	if rule == nil {
+		return nil, nil
+	}
+
+	if md.IsStreamingClient() {
+		return nil, fmt.Errorf("%s: client and bidi streaming methods can't be transcoded", md.FullName())
+	}
+
+	input, err := protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", md.FullName(), err)
+	}
+
+	output, err := protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", md.FullName(), err)
+	}
+
+	rules := append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...)
+	bindings := make([]*Binding, 0, len(rules))
+
+	for _, rule := range rules {
+		binding, err := newBinding(md, rule)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", md.FullName(), err)
+		}
+
+		binding.input = input
+		binding.output = output
+		bindings = append(bindings, binding)
+	}
+
+	return bindings, nil
+}
+
+func newBinding(md protoreflect.MethodDescriptor, rule *annotations.HttpRule) (*Binding, error) {
+	binding := &Binding{
+		Body:         rule.GetBody(),
+		ResponseBody: rule.GetResponseBody(),
+		Method:       md,
+	}
+
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		binding.Verb, binding.Template = http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Put:
+		binding.Verb, binding.Template = http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Post:
+		binding.Verb, binding.Template = http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Delete:
+		binding.Verb, binding.Template = http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Patch:
+		binding.Verb, binding.Template = http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Custom:
+		binding.Verb, binding.Template = strings.ToUpper(pattern.Custom.GetKind()), pattern.Custom.GetPath()
+	default:
+		return nil, fmt.Errorf("http rule has no pattern")
+	}
+
+	if binding.Body != "" && binding.Body != "*" && md.Input().Fields().ByName(protoreflect.Name(binding.Body)) == nil {
+		return nil, fmt.Errorf("body field %q does not exist", binding.Body)
+	}
+
+	if binding.ResponseBody != "" && md.Output().Fields().ByName(protoreflect.Name(binding.ResponseBody)) == nil {
+		return nil, fmt.Errorf("response_body field %q does not exist", binding.ResponseBody)
+	}
+
+	echoPath, params, err := parseTemplate(binding.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, param := range params {
+		if _, err := findField(md.Input(), param); err != nil {
+			return nil, fmt.Errorf("path %q: %w", binding.Template, err)
+		}
+	}
+
+	binding.echoPath = echoPath
+	binding.PathParams = params
+
+	return binding, nil
+}
+
+// parseTemplate converts a google.api.http path template into an echo route.
+// Only the common subset of the template syntax is supported: literal
+// segments, single segment variables ({name} or {name=*}) and a trailing
+// multi-segment variable ({name=**}). Each variable becomes an echo parameter
+// named p0, p1, ... (or the "*" wildcard for a trailing {name=**}).
+func parseTemplate(tmpl string) (string, []string, error) {
+	if !strings.HasPrefix(tmpl, "/") {
+		return "", nil, fmt.Errorf("path %q must start with /", tmpl)
+	}
+
+	if strings.Contains(tmpl[strings.LastIndex(tmpl, "/"):], ":") {
+		return "", nil, fmt.Errorf("path %q: custom verbs are not supported", tmpl)
+	}
+
+	var (
+		segments []string
+		params   []string
+	)
+
+	for rest := tmpl[1:]; rest != ""; {
+		var segment string
+
+		if strings.HasPrefix(rest, "{") {
+			end := strings.Index(rest, "}")
+			if end < 0 {
+				return "", nil, fmt.Errorf("path %q: unterminated variable", tmpl)
+			}
+
+			segment, rest = rest[:end+1], rest[end+1:]
+		} else if idx := strings.Index(rest, "/"); idx >= 0 {
+			segment, rest = rest[:idx], rest[idx:]
+		} else {
+			segment, rest = rest, ""
+		}
+
+		rest = strings.TrimPrefix(rest, "/")
+
+		if !strings.HasPrefix(segment, "{") {
+			if segment == "" || strings.Contains(segment, "*") {
+				return "", nil, fmt.Errorf("path %q: wildcard segments must be bound to a field", tmpl)
+			}
+
+			segments = append(segments, segment)
+
+			continue
+		}
+
+		field, pattern, _ := strings.Cut(segment[1:len(segment)-1], "=")
+
+		switch pattern {
+		case "", "*":
+			segments = append(segments, fmt.Sprintf(":p%d", len(params)))
+		case "**":
+			if rest != "" {
+				return "", nil, fmt.Errorf("path %q: {%s=**} must be the last segment", tmpl, field)
+			}
+
+			segments = append(segments, "*")
+		default:
+			return "", nil, fmt.Errorf("path %q: unsupported variable pattern %q", tmpl, pattern)
+		}
+
+		params = append(params, field)
+	}
+
+	return "/" + strings.Join(segments, "/"), params, nil
+}
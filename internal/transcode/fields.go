@@ -0,0 +1,153 @@
+package transcode
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// findField resolves a dotted field path (like "request.name") against md.
+// Each part may use either the proto name or the JSON name of the field.
+func findField(md protoreflect.MessageDescriptor, path string) ([]protoreflect.FieldDescriptor, error) {
+	parts := strings.Split(path, ".")
+	fields := make([]protoreflect.FieldDescriptor, 0, len(parts))
+
+	for idx, part := range parts {
+		fd := md.Fields().ByName(protoreflect.Name(part))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(part)
+		}
+
+		if fd == nil {
+			return nil, fmt.Errorf("unknown field %q", path)
+		}
+
+		if idx < len(parts)-1 {
+			if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+				return nil, fmt.Errorf("field %q is not a singular message", strings.Join(parts[:idx+1], "."))
+			}
+
+			md = fd.Message()
+		}
+
+		fields = append(fields, fd)
+	}
+
+	return fields, nil
+}
+
+// setField parses values and stores them in the field at path. Repeated
+// fields receive every value, singular fields the last one.
+func setField(msg protoreflect.Message, path string, values []string) error {
+	fields, err := findField(msg.Descriptor(), path)
+	if err != nil {
+		return err
+	}
+
+	for _, fd := range fields[:len(fields)-1] {
+		msg = msg.Mutable(fd).Message()
+	}
+
+	fd := fields[len(fields)-1]
+
+	if fd.IsMap() {
+		return fmt.Errorf("map field %q can't be set from a path or query parameter", path)
+	}
+
+	if fd.IsList() {
+		list := msg.Mutable(fd).List()
+
+		for _, raw := range values {
+			val, err := parseValue(fd, list.NewElement, raw)
+			if err != nil {
+				return fmt.Errorf("field %q: %w", path, err)
+			}
+
+			list.Append(val)
+		}
+
+		return nil
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	val, err := parseValue(fd, func() protoreflect.Value { return msg.NewField(fd) }, values[len(values)-1])
+	if err != nil {
+		return fmt.Errorf("field %q: %w", path, err)
+	}
+
+	msg.Set(fd, val)
+
+	return nil
+}
+
+// parseValue converts the string form of a value into a protoreflect.Value.
+// Messages (mostly well-known types like Timestamp and Duration) are parsed
+// from their protojson string form.
+func parseValue(fd protoreflect.FieldDescriptor, newValue func() protoreflect.Value, raw string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(raw), nil
+	case protoreflect.BytesKind:
+		data, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil {
+			data, err = base64.URLEncoding.DecodeString(raw)
+		}
+
+		return protoreflect.ValueOfBytes(data), err
+	case protoreflect.BoolKind:
+		val, err := strconv.ParseBool(raw)
+
+		return protoreflect.ValueOfBool(val), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		val, err := strconv.ParseInt(raw, 10, 32)
+
+		return protoreflect.ValueOfInt32(int32(val)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		val, err := strconv.ParseInt(raw, 10, 64)
+
+		return protoreflect.ValueOfInt64(val), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		val, err := strconv.ParseUint(raw, 10, 32)
+
+		return protoreflect.ValueOfUint32(uint32(val)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		val, err := strconv.ParseUint(raw, 10, 64)
+
+		return protoreflect.ValueOfUint64(val), err
+	case protoreflect.FloatKind:
+		val, err := strconv.ParseFloat(raw, 32)
+
+		return protoreflect.ValueOfFloat32(float32(val)), err
+	case protoreflect.DoubleKind:
+		val, err := strconv.ParseFloat(raw, 64)
+
+		return protoreflect.ValueOfFloat64(val), err
+	case protoreflect.EnumKind:
+		if enumVal := fd.Enum().Values().ByName(protoreflect.Name(raw)); enumVal != nil {
+			return protoreflect.ValueOfEnum(enumVal.Number()), nil
+		}
+
+		val, err := strconv.ParseInt(raw, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("unknown enum value %q", raw)
+		}
+
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(val)), nil
+	case protoreflect.MessageKind:
+		val := newValue()
+		if err := protojson.Unmarshal([]byte(strconv.Quote(raw)), val.Message().Interface()); err != nil {
+			return protoreflect.Value{}, err
+		}
+
+		return val, nil
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+	}
+}
@@ -0,0 +1,285 @@
+package transcode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"github.com/fernferret/wab/internal/apierr"
+)
+
+// SSEContentType is the content type of a Server-Sent Events stream.
+const SSEContentType = "text/event-stream"
+
+// sseKeepAlive is how often a comment is sent on an idle stream so proxies
+// don't time the connection out.
+const sseKeepAlive = 15 * time.Second
+
+// SSEHandler exposes every server-streaming method as a Server-Sent Events
+// stream. Each response is sent as a "message" event holding the protojson
+// encoded message, and the stream always ends with a single "status" event
+// holding a problem document (with the code "OK" on success).
+type SSEHandler struct {
+	conn    grpc.ClientConnInterface
+	methods []*sseMethod
+	log     *zap.SugaredLogger
+
+	marshal   protojson.MarshalOptions
+	unmarshal protojson.UnmarshalOptions
+}
+
+type sseMethod struct {
+	desc   protoreflect.MethodDescriptor
+	input  protoreflect.MessageType
+	output protoreflect.MessageType
+	// service is the name the method is served and called under, the
+	// parent's full name unless it's an alias.
+	service string
+	alias   bool
+}
+
+// NewSSE finds every server-streaming method in services. Calls are sent to
+// conn.
+func NewSSE(conn grpc.ClientConnInterface, services ...protoreflect.ServiceDescriptor) (*SSEHandler, error) {
+	handler := &SSEHandler{
+		conn: conn,
+		log:  zap.S().With("part", "sse"),
+		marshal: protojson.MarshalOptions{
+			EmitUnpopulated: true,
+		},
+	}
+
+	for _, sd := range services {
+		methods := sd.Methods()
+		for idx := 0; idx < methods.Len(); idx++ {
+			md := methods.Get(idx)
+			if !md.IsStreamingServer() || md.IsStreamingClient() {
+				continue
+			}
+
+			input, err := protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", md.FullName(), err)
+			}
+
+			output, err := protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName())
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", md.FullName(), err)
+			}
+
+			handler.methods = append(handler.methods, &sseMethod{
+				desc:    md,
+				input:   input,
+				output:  output,
+				service: string(sd.FullName()),
+			})
+		}
+	}
+
+	return handler, nil
+}
+
+// Alias serves every method of the service target a second time under the
+// service name alias. Calls are sent to /<alias>/<method>, so conn needs the
+// alias registered too.
+func (h *SSEHandler) Alias(alias, target string) {
+	for _, m := range h.methods {
+		if m.alias || m.service != target {
+			continue
+		}
+
+		aliased := *m
+		aliased.service = alias
+		aliased.alias = true
+		h.methods = append(h.methods, &aliased)
+	}
+}
+
+// Methods returns the full name of every method served as an event stream,
+// aliases aside.
+func (h *SSEHandler) Methods() []string {
+	names := make([]string, 0, len(h.methods))
+	for _, method := range h.methods {
+		if !method.alias {
+			names = append(names, string(method.desc.FullName()))
+		}
+	}
+
+	return names
+}
+
+// Register adds a GET and a POST route under prefix for every method, for
+// example "<prefix>/wab.greeter.v1.Greeter/GreetMany". GET requests are built
+// from the query string (like the REST routes), POST requests from a protojson
+// body.
+func (h *SSEHandler) Register(router Router, prefix string) {
+	for _, method := range h.methods {
+		path := fmt.Sprintf("%s/%s/%s", prefix, method.service, method.desc.Name())
+		h.log.Debugf("SSE %s -> %s", path, method.desc.FullName())
+
+		router.Add(http.MethodGet, path, h.handle(method))
+		router.Add(http.MethodPost, path, h.handle(method))
+	}
+}
+
+func (h *SSEHandler) handle(method *sseMethod) echo.HandlerFunc {
+	fullMethod := fmt.Sprintf("/%s/%s", method.service, method.desc.Name())
+
+	return func(ectx echo.Context) error {
+		req := method.input.New().Interface()
+		if err := h.decodeRequest(ectx, req); err != nil {
+			return err
+		}
+
+		// The request context is cancelled when the client goes away, which
+		// cancels the RPC just like a closed grpcweb stream does.
+		ctx := ectx.Request().Context()
+
+		stream, err := h.conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, fullMethod)
+		if err != nil {
+			return err
+		}
+
+		if err := stream.SendMsg(req); err != nil {
+			return err
+		}
+
+		if err := stream.CloseSend(); err != nil {
+			return err
+		}
+
+		resp := ectx.Response()
+		resp.Header().Set(echo.HeaderContentType, SSEContentType)
+		resp.Header().Set(echo.HeaderCacheControl, "no-cache")
+		// Stop nginx from buffering the stream.
+		resp.Header().Set("X-Accel-Buffering", "no")
+		resp.WriteHeader(http.StatusOK)
+		resp.Flush()
+
+		messages := make(chan proto.Message)
+		result := make(chan error, 1)
+
+		go func() {
+			defer close(messages)
+
+			for {
+				msg := method.output.New().Interface()
+				if err := stream.RecvMsg(msg); err != nil {
+					result <- err
+
+					return
+				}
+
+				select {
+				case messages <- msg:
+				case <-ctx.Done():
+					result <- ctx.Err()
+
+					return
+				}
+			}
+		}()
+
+		keepAlive := time.NewTicker(sseKeepAlive)
+		defer keepAlive.Stop()
+
+		for id := 0; ; {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					h.writeStatus(ectx, <-result)
+
+					return nil
+				}
+
+				data, err := h.marshal.Marshal(msg)
+				if err != nil {
+					return err
+				}
+
+				writeEvent(resp, "message", fmt.Sprint(id), data)
+				id++
+			case <-keepAlive.C:
+				_, _ = io.WriteString(resp, ": keep-alive\n\n")
+				resp.Flush()
+			case <-ctx.Done():
+				// Nobody is listening anymore, the RPC was cancelled along with
+				// the context.
+				return nil
+			}
+		}
+	}
+}
+
+// writeStatus sends the terminal "status" event.
+func (h *SSEHandler) writeStatus(ectx echo.Context, err error) {
+	st := status.New(codes.OK, "")
+	if !errors.Is(err, io.EOF) {
+		st = status.Convert(err)
+	}
+
+	problem := apierr.StatusProblem(st)
+	problem.Instance = ectx.Request().URL.Path
+
+	data, err := json.Marshal(problem)
+	if err != nil {
+		h.log.With(zap.Error(err)).Error("Unable to encode the final stream status")
+
+		return
+	}
+
+	writeEvent(ectx.Response(), "status", "", data)
+}
+
+func (h *SSEHandler) decodeRequest(ectx echo.Context, req proto.Message) error {
+	if ectx.Request().Method == http.MethodPost {
+		body, err := io.ReadAll(ectx.Request().Body)
+		if err != nil {
+			return apierr.BadRequest(apierr.ReasonMalformedRequest, "unable to read request body")
+		}
+
+		if len(body) == 0 {
+			return nil
+		}
+
+		if err := h.unmarshal.Unmarshal(body, req); err != nil {
+			return apierr.BadRequest(apierr.ReasonMalformedRequest, "invalid JSON request body",
+				apierr.Violation("*", err.Error()))
+		}
+
+		return nil
+	}
+
+	for key, values := range ectx.QueryParams() {
+		if err := setField(req.ProtoReflect(), key, values); err != nil {
+			return apierr.BadRequest(apierr.ReasonMalformedRequest, "invalid query parameter",
+				apierr.Violation(key, err.Error()))
+		}
+	}
+
+	return nil
+}
+
+func writeEvent(resp *echo.Response, event, id string, data []byte) {
+	if id != "" {
+		fmt.Fprintf(resp, "id: %s\n", id)
+	}
+
+	// protojson output never contains newlines unless Multiline is set, so the
+	// data always fits on a single data line.
+	fmt.Fprintf(resp, "event: %s\ndata: %s\n\n", event, data)
+	resp.Flush()
+}
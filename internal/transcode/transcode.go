@@ -0,0 +1,284 @@
+// Package transcode exposes gRPC methods annotated with google.api.http rules
+// as REST/JSON routes on echo. Requests and responses are encoded with
+// protojson and every call is dispatched through a grpc.ClientConnInterface,
+// normally the same in-process channel grpcui uses, so interceptors and error
+// details behave exactly like they do over gRPC.
+package transcode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/fernferret/wab/internal/apierr"
+)
+
+// NDJSONContentType is the content type of server-streaming responses, one
+// protojson message per line.
+const NDJSONContentType = "application/x-ndjson"
+
+// Router is the part of echo.Echo (and echo.Group) used to register routes.
+type Router interface {
+	Add(method, path string, handler echo.HandlerFunc, middleware ...echo.MiddlewareFunc) *echo.Route
+}
+
+// Handler holds the bindings of every annotated method.
+type Handler struct {
+	conn     grpc.ClientConnInterface
+	bindings []*Binding
+	log      *zap.SugaredLogger
+
+	marshal   protojson.MarshalOptions
+	unmarshal protojson.UnmarshalOptions
+}
+
+// New collects the google.api.http bindings of every method in services.
+// Calls are sent to conn.
+func New(conn grpc.ClientConnInterface, services ...protoreflect.ServiceDescriptor) (*Handler, error) {
+	handler := &Handler{
+		conn: conn,
+		log:  zap.S().With("part", "rest"),
+		marshal: protojson.MarshalOptions{
+			EmitUnpopulated: true,
+		},
+	}
+
+	for _, sd := range services {
+		methods := sd.Methods()
+		for idx := 0; idx < methods.Len(); idx++ {
+			bindings, err := bindingsFor(methods.Get(idx))
+			if err != nil {
+				return nil, err
+			}
+
+			handler.bindings = append(handler.bindings, bindings...)
+		}
+	}
+
+	return handler, nil
+}
+
+// Bindings returns every binding the handler serves.
+func (h *Handler) Bindings() []*Binding {
+	return h.bindings
+}
+
+// Register adds a route for every binding to router.
+func (h *Handler) Register(router Router) {
+	for _, binding := range h.bindings {
+		h.log.Debugf("%s %s -> %s", binding.Verb, binding.Template, binding.FullMethod())
+		router.Add(binding.Verb, binding.echoPath, h.handle(binding))
+	}
+}
+
+// Streaming reports whether ectx was routed to a server-streaming binding,
+// its response lasts as long as the stream. It's meant for middleware, which
+// runs after routing.
+func (h *Handler) Streaming(ectx echo.Context) bool {
+	method := ectx.Request().Method
+
+	for _, binding := range h.bindings {
+		if binding.ServerStreaming() && binding.Verb == method && binding.echoPath == ectx.Path() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *Handler) handle(binding *Binding) echo.HandlerFunc {
+	return func(ectx echo.Context) error {
+		req := binding.input.New().Interface()
+
+		if err := h.decodeRequest(ectx, binding, req); err != nil {
+			return err
+		}
+
+		if binding.ServerStreaming() {
+			return h.stream(ectx, binding, req)
+		}
+
+		resp := binding.output.New().Interface()
+
+		if err := h.conn.Invoke(ectx.Request().Context(), binding.FullMethod(), req, resp); err != nil {
+			return err
+		}
+
+		data, err := h.encodeResponse(binding, resp)
+		if err != nil {
+			return err
+		}
+
+		return ectx.JSONBlob(http.StatusOK, data)
+	}
+}
+
+// stream forwards every message of a server-streaming call as a line of
+// newline delimited JSON. Errors before the first message are returned
+// normally (and become a problem document), errors after that are sent as a
+// final {"error": {...}} line since the status code is already gone.
+func (h *Handler) stream(ectx echo.Context, binding *Binding, req proto.Message) error {
+	ctx := ectx.Request().Context()
+	desc := &grpc.StreamDesc{ServerStreams: true}
+
+	stream, err := h.conn.NewStream(ctx, desc, binding.FullMethod())
+	if err != nil {
+		return err
+	}
+
+	if err := stream.SendMsg(req); err != nil {
+		return err
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+
+	resp := ectx.Response()
+	started := false
+
+	for {
+		msg := binding.output.New().Interface()
+
+		err := stream.RecvMsg(msg)
+		if errors.Is(err, io.EOF) {
+			if !started {
+				return ectx.NoContent(http.StatusOK)
+			}
+
+			return nil
+		}
+
+		if err != nil && !started {
+			return err
+		}
+
+		// The stream is over after an error, later RecvMsg calls just repeat it.
+		if err != nil {
+			problem := apierr.StatusProblem(status.Convert(err))
+			problem.Instance = ectx.Request().URL.Path
+
+			line, err := json.Marshal(map[string]interface{}{"error": problem})
+			if err != nil {
+				return err
+			}
+
+			_, _ = resp.Write(append(line, '\n'))
+			resp.Flush()
+
+			return nil
+		}
+
+		line, err := h.encodeResponse(binding, msg)
+		if err != nil {
+			return err
+		}
+
+		if !started {
+			resp.Header().Set(echo.HeaderContentType, NDJSONContentType)
+			resp.WriteHeader(http.StatusOK)
+
+			started = true
+		}
+
+		if _, err := resp.Write(append(line, '\n')); err != nil {
+			return nil
+		}
+
+		resp.Flush()
+	}
+}
+
+// decodeRequest fills req from the body, the path parameters and the query
+// string, in that order.
+func (h *Handler) decodeRequest(ectx echo.Context, binding *Binding, req proto.Message) error {
+	msg := req.ProtoReflect()
+
+	if binding.Body != "" {
+		body, err := io.ReadAll(ectx.Request().Body)
+		if err != nil {
+			return apierr.BadRequest(apierr.ReasonMalformedRequest, "unable to read request body")
+		}
+
+		if len(body) > 0 {
+			if binding.Body != "*" {
+				// Wrap the body so it can be decoded as the single field.
+				name := msg.Descriptor().Fields().ByName(protoreflect.Name(binding.Body)).JSONName()
+				body = []byte(fmt.Sprintf("{%q:%s}", name, body))
+			}
+
+			if err := h.unmarshal.Unmarshal(body, req); err != nil {
+				return apierr.BadRequest(apierr.ReasonMalformedRequest, "invalid JSON request body",
+					apierr.Violation(binding.Body, err.Error()))
+			}
+		}
+	}
+
+	for idx, field := range binding.PathParams {
+		name := fmt.Sprintf("p%d", idx)
+		if idx == len(binding.PathParams)-1 && strings.HasSuffix(binding.echoPath, "/*") {
+			name = "*"
+		}
+
+		raw, err := url.PathUnescape(ectx.Param(name))
+		if err != nil {
+			raw = ectx.Param(name)
+		}
+
+		if err := setField(msg, field, []string{raw}); err != nil {
+			return apierr.BadRequest(apierr.ReasonMalformedRequest, "invalid path parameter",
+				apierr.Violation(field, err.Error()))
+		}
+	}
+
+	// Query parameters only fill fields that aren't already covered by the
+	// body.
+	if binding.Body == "*" {
+		return nil
+	}
+
+	for key, values := range ectx.QueryParams() {
+		if binding.Body != "" && (key == binding.Body || strings.HasPrefix(key, binding.Body+".")) {
+			continue
+		}
+
+		if err := setField(msg, key, values); err != nil {
+			return apierr.BadRequest(apierr.ReasonMalformedRequest, "invalid query parameter",
+				apierr.Violation(key, err.Error()))
+		}
+	}
+
+	return nil
+}
+
+// encodeResponse marshals resp, or just its response_body field.
+func (h *Handler) encodeResponse(binding *Binding, resp proto.Message) ([]byte, error) {
+	data, err := h.marshal.Marshal(resp)
+	if err != nil || binding.ResponseBody == "" {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	name := resp.ProtoReflect().Descriptor().Fields().ByName(protoreflect.Name(binding.ResponseBody)).JSONName()
+	if field, ok := fields[name]; ok {
+		return field, nil
+	}
+
+	return []byte("null"), nil
+}
@@ -0,0 +1,174 @@
+package transcode
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fullstorydev/grpchan/inprocgrpc"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gpbv2 "github.com/fernferret/wab/gen/greeterv2pb"
+)
+
+func TestParseTemplate(t *testing.T) {
+	cases := []struct {
+		tmpl   string
+		path   string
+		params []string
+		err    bool
+	}{
+		{tmpl: "/api/v1/greet", path: "/api/v1/greet"},
+		{tmpl: "/api/v1/greet/{name}", path: "/api/v1/greet/:p0", params: []string{"name"}},
+		{tmpl: "/api/v1/greet/{name=*}", path: "/api/v1/greet/:p0", params: []string{"name"}},
+		{tmpl: "/api/{request.name}/many/{qty}", path: "/api/:p0/many/:p1", params: []string{"request.name", "qty"}},
+		{tmpl: "/files/{path=**}", path: "/files/*", params: []string{"path"}},
+		{tmpl: "api/v1/greet", err: true},
+		{tmpl: "/api/v1/greet:run", err: true},
+		{tmpl: "/api/*/greet", err: true},
+		{tmpl: "/api//greet", err: true},
+		{tmpl: "/api/{name", err: true},
+		{tmpl: "/files/{path=**}/more", err: true},
+		{tmpl: "/files/{path=docs/*}", err: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.tmpl, func(t *testing.T) {
+			path, params, err := parseTemplate(tc.tmpl)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %q %v", path, params)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if path != tc.path || !reflect.DeepEqual(params, tc.params) {
+				t.Errorf("got %q %v, want %q %v", path, params, tc.path, tc.params)
+			}
+		})
+	}
+}
+
+// failingGreeter sends qty greetings and fails after failAfter of them when
+// failAfter isn't negative.
+type failingGreeter struct {
+	gpbv2.UnimplementedGreeterServer
+
+	failAfter int
+}
+
+func (g *failingGreeter) GreetMany(req *gpbv2.GreetManyRequest, svr gpbv2.Greeter_GreetManyServer) error {
+	for idx := 0; idx < int(req.GetQty()); idx++ {
+		if idx == g.failAfter {
+			return status.Error(codes.Unavailable, "greeter went away")
+		}
+
+		if err := svr.Send(&gpbv2.GreetResponse{Message: "Hi " + req.GetName(), Index: uint32(idx)}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestStream(t *testing.T) {
+	cases := []struct {
+		name      string
+		failAfter int
+		status    int
+		lines     []string
+	}{
+		{
+			name:      "complete",
+			failAfter: -1,
+			status:    http.StatusOK,
+			lines:     []string{"message", "message", "message"},
+		},
+		{
+			name:      "fails after the first message",
+			failAfter: 1,
+			status:    http.StatusOK,
+			lines:     []string{"message", "error"},
+		},
+		{
+			name:      "fails before the first message",
+			failAfter: 0,
+			status:    http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			channel := &inprocgrpc.Channel{}
+			gpbv2.RegisterHandlerGreeter(channel, &failingGreeter{failAfter: tc.failAfter})
+
+			handler, err := New(channel, gpbv2.File_greeter_v2_proto.Services().Get(0))
+			if err != nil {
+				t.Fatalf("failed to load the bindings: %v", err)
+			}
+
+			e := echo.New()
+			handler.Register(e)
+
+			svr := httptest.NewServer(e)
+			defer svr.Close()
+
+			// A stream that doesn't end would run into the timeout.
+			client := &http.Client{Timeout: 5 * time.Second}
+
+			resp, err := client.Get(svr.URL + "/api/v2/greet-many/bob?qty=3")
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.status {
+				t.Fatalf("got status %d, want %d", resp.StatusCode, tc.status)
+			}
+
+			if tc.status != http.StatusOK {
+				return
+			}
+
+			if got := resp.Header.Get(echo.HeaderContentType); got != NDJSONContentType {
+				t.Errorf("got content type %q, want %q", got, NDJSONContentType)
+			}
+
+			var lines []string
+
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				var line map[string]json.RawMessage
+				if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+					t.Fatalf("line %q isn't JSON: %v", scanner.Text(), err)
+				}
+
+				kind := "message"
+				if _, ok := line["error"]; ok {
+					kind = "error"
+				}
+
+				lines = append(lines, kind)
+			}
+
+			if err := scanner.Err(); err != nil {
+				t.Fatalf("failed to read the stream: %v", err)
+			}
+
+			if !reflect.DeepEqual(lines, tc.lines) {
+				t.Errorf("got lines %v, want %v", lines, tc.lines)
+			}
+		})
+	}
+}
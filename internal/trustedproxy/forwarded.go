@@ -0,0 +1,197 @@
+package trustedproxy
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// forwardingHeaders are dropped from every request once they've been read,
+// so nothing further down (echo's RealIP and Scheme included) trusts what a
+// client made up.
+var forwardingHeaders = []string{
+	"Forwarded",
+	echo.HeaderXForwardedFor,
+	echo.HeaderXForwardedProto,
+	echo.HeaderXForwardedProtocol,
+	echo.HeaderXForwardedSsl,
+	echo.HeaderXUrlScheme,
+	"X-Forwarded-Host",
+	echo.HeaderXRealIP,
+}
+
+// Client is who sent a request, as far as the trusted proxies say.
+type Client struct {
+	// Addr is the client's host:port, the port is 0 when the proxies didn't
+	// pass it on.
+	Addr string
+	// Proto is http or https, as the client used it.
+	Proto string
+	// Host is the Host the client asked for.
+	Host string
+}
+
+// hop is what one proxy says about the connection it received.
+type hop struct {
+	forAddr string
+	proto   string
+	host    string
+}
+
+// Resolve works out the client of req. Hops are read from the nearest proxy
+// outwards for as long as they come from trusted proxies, the first untrusted
+// address is the client. The Forwarded header wins over X-Forwarded-*.
+func (t *Trusted) Resolve(req *http.Request) Client {
+	client := Client{Addr: req.RemoteAddr, Proto: "http", Host: req.Host}
+	if req.TLS != nil {
+		client.Proto = "https"
+	}
+
+	if !t.Contains(addrIP(req.RemoteAddr)) {
+		return client
+	}
+
+	hops := forwardedHops(req.Header)
+	for idx := len(hops) - 1; idx >= 0; idx-- {
+		ip, port := parseNode(hops[idx].forAddr)
+		if ip == nil {
+			break
+		}
+
+		client.Addr = net.JoinHostPort(ip.String(), port)
+
+		if proto := strings.ToLower(hops[idx].proto); proto == "http" || proto == "https" {
+			client.Proto = proto
+		}
+
+		if host := hops[idx].host; host != "" && !strings.ContainsAny(host, "/ \t") {
+			client.Host = host
+		}
+
+		if !t.Contains(ip) {
+			break
+		}
+	}
+
+	return client
+}
+
+// Middleware replaces the address, scheme and host of every request with the
+// resolved client. RemoteAddr is what grpcweb hands to gRPC as the peer,
+// X-Forwarded-Proto is left with the resolved scheme for echo's Scheme. Pair
+// it with echo.ExtractIPDirect so RealIP reads the resolved address. Register
+// it with echo.Pre.
+func (t *Trusted) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ectx echo.Context) error {
+			req := ectx.Request()
+			client := t.Resolve(req)
+
+			for _, header := range forwardingHeaders {
+				req.Header.Del(header)
+			}
+
+			req.RemoteAddr = client.Addr
+			req.Host = client.Host
+			req.Header.Set(echo.HeaderXForwardedProto, client.Proto)
+
+			return next(ectx)
+		}
+	}
+}
+
+// forwardedHops lists the hops of the Forwarded header, or of the
+// X-Forwarded-* headers when there isn't one, client first.
+func forwardedHops(header http.Header) []hop {
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		var hops []hop
+
+		for _, element := range splitList(values) {
+			var h hop
+
+			for _, pair := range strings.Split(element, ";") {
+				key, value, _ := strings.Cut(strings.TrimSpace(pair), "=")
+				value = strings.Trim(value, `"`)
+
+				switch strings.ToLower(key) {
+				case "for":
+					h.forAddr = value
+				case "proto":
+					h.proto = value
+				case "host":
+					h.host = value
+				}
+			}
+
+			hops = append(hops, h)
+		}
+
+		return hops
+	}
+
+	fors := splitList(header.Values(echo.HeaderXForwardedFor))
+	protos := splitList(header.Values(echo.HeaderXForwardedProto))
+	hosts := splitList(header.Values("X-Forwarded-Host"))
+
+	hops := make([]hop, len(fors))
+	for idx, forAddr := range fors {
+		hops[idx] = hop{
+			forAddr: forAddr,
+			proto:   aligned(protos, idx, len(fors)),
+			host:    aligned(hosts, idx, len(fors)),
+		}
+	}
+
+	return hops
+}
+
+// aligned is the value of values for hop idx of count. Proxies that append
+// keep the lists in step with X-Forwarded-For, otherwise the last value, from
+// the nearest proxy, is all we can go on.
+func aligned(values []string, idx, count int) string {
+	switch {
+	case len(values) == count:
+		return values[idx]
+	case len(values) > 0 && idx == count-1:
+		return values[len(values)-1]
+	}
+
+	return ""
+}
+
+func splitList(values []string) []string {
+	var items []string
+
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+
+	return items
+}
+
+// parseNode parses a node of Forwarded or X-Forwarded-For: 192.0.2.43,
+// 192.0.2.43:47011, [2001:db8::1]:4711 or 2001:db8::1. Obfuscated and
+// unknown nodes have no IP.
+func parseNode(node string) (net.IP, string) {
+	if ip := net.ParseIP(strings.Trim(node, "[]")); ip != nil {
+		return ip, "0"
+	}
+
+	host, port, err := net.SplitHostPort(node)
+	if err != nil {
+		return nil, ""
+	}
+
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		port = "0"
+	}
+
+	return net.ParseIP(host), port
+}
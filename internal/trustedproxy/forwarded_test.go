@@ -0,0 +1,173 @@
+package trustedproxy
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestResolve(t *testing.T) {
+	trusted, err := Parse([]string{"10.0.0.0/8", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		remoteAddr string
+		tls        bool
+		header     http.Header
+		want       Client
+	}{
+		{
+			name:       "untrusted peer keeps its address",
+			remoteAddr: "198.51.100.7:4711",
+			header:     http.Header{"X-Forwarded-For": {"203.0.113.5"}, "X-Forwarded-Proto": {"https"}},
+			want:       Client{Addr: "198.51.100.7:4711", Proto: "http", Host: "wab.example"},
+		},
+		{
+			name:       "untrusted peer over TLS",
+			remoteAddr: "198.51.100.7:4711",
+			tls:        true,
+			header:     http.Header{"X-Forwarded-Proto": {"http"}},
+			want:       Client{Addr: "198.51.100.7:4711", Proto: "https", Host: "wab.example"},
+		},
+		{
+			name:       "trusted proxy without headers",
+			remoteAddr: "10.0.0.1:4711",
+			want:       Client{Addr: "10.0.0.1:4711", Proto: "http", Host: "wab.example"},
+		},
+		{
+			name:       "X-Forwarded-For",
+			remoteAddr: "10.0.0.1:4711",
+			header: http.Header{
+				"X-Forwarded-For":   {"203.0.113.5"},
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"app.example"},
+			},
+			want: Client{Addr: "203.0.113.5:0", Proto: "https", Host: "app.example"},
+		},
+		{
+			name:       "spoofed X-Forwarded-For entries are skipped",
+			remoteAddr: "10.0.0.1:4711",
+			header:     http.Header{"X-Forwarded-For": {"1.2.3.4, 10.9.9.9", "203.0.113.5"}},
+			want:       Client{Addr: "203.0.113.5:0", Proto: "http", Host: "wab.example"},
+		},
+		{
+			name:       "chain of trusted proxies",
+			remoteAddr: "10.0.0.1:4711",
+			header: http.Header{
+				"X-Forwarded-For":   {"203.0.113.5, 10.0.0.2"},
+				"X-Forwarded-Proto": {"https, http"},
+			},
+			want: Client{Addr: "203.0.113.5:0", Proto: "https", Host: "wab.example"},
+		},
+		{
+			name:       "only the nearest proto",
+			remoteAddr: "10.0.0.1:4711",
+			header: http.Header{
+				"X-Forwarded-For":   {"203.0.113.5, 10.0.0.2"},
+				"X-Forwarded-Proto": {"https"},
+			},
+			want: Client{Addr: "203.0.113.5:0", Proto: "https", Host: "wab.example"},
+		},
+		{
+			name:       "Forwarded wins over X-Forwarded-For",
+			remoteAddr: "10.0.0.1:4711",
+			header: http.Header{
+				"Forwarded":       {`for=198.51.100.7;proto=https;host="app.example"`},
+				"X-Forwarded-For": {"1.2.3.4"},
+			},
+			want: Client{Addr: "198.51.100.7:0", Proto: "https", Host: "app.example"},
+		},
+		{
+			name:       "spoofed Forwarded elements are skipped",
+			remoteAddr: "10.0.0.1:4711",
+			header:     http.Header{"Forwarded": {"for=1.2.3.4, for=203.0.113.5;proto=https"}},
+			want:       Client{Addr: "203.0.113.5:0", Proto: "https", Host: "wab.example"},
+		},
+		{
+			name:       "Forwarded IPv6 node with a port",
+			remoteAddr: "[::1]:4711",
+			header:     http.Header{"Forwarded": {`For="[2001:db8::1]:4711"`}},
+			want:       Client{Addr: "[2001:db8::1]:4711", Proto: "http", Host: "wab.example"},
+		},
+		{
+			name:       "bare IPv6 X-Forwarded-For",
+			remoteAddr: "[::1]:4711",
+			header:     http.Header{"X-Forwarded-For": {"2001:db8::1"}},
+			want:       Client{Addr: "[2001:db8::1]:0", Proto: "http", Host: "wab.example"},
+		},
+		{
+			name:       "obfuscated node stops the walk",
+			remoteAddr: "10.0.0.1:4711",
+			header:     http.Header{"Forwarded": {"for=203.0.113.5, for=_hidden, for=10.0.0.2"}},
+			want:       Client{Addr: "10.0.0.2:0", Proto: "http", Host: "wab.example"},
+		},
+		{
+			name:       "unknown node",
+			remoteAddr: "10.0.0.1:4711",
+			header:     http.Header{"Forwarded": {"for=unknown;proto=https"}},
+			want:       Client{Addr: "10.0.0.1:4711", Proto: "http", Host: "wab.example"},
+		},
+		{
+			name:       "invalid proto and host are ignored",
+			remoteAddr: "10.0.0.1:4711",
+			header:     http.Header{"Forwarded": {`for=203.0.113.5;proto=javascript;host="evil.example/x"`}},
+			want:       Client{Addr: "203.0.113.5:0", Proto: "http", Host: "wab.example"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://wab.example/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			req.Header = tc.header
+
+			if tc.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+
+			if got := trusted.Resolve(req); got != tc.want {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	trusted, err := Parse([]string{"10.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.IPExtractor = echo.ExtractIPDirect()
+	e.Pre(trusted.Middleware())
+	e.GET("/", func(ectx echo.Context) error {
+		req := ectx.Request()
+		for _, header := range []string{"Forwarded", "X-Forwarded-For", "X-Real-Ip"} {
+			if req.Header.Get(header) != "" {
+				t.Errorf("%s was passed on", header)
+			}
+		}
+
+		return ectx.String(http.StatusOK, ectx.RealIP()+" "+ectx.Scheme()+" "+req.Host)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "http://wab.example/", nil)
+	req.RemoteAddr = "10.0.0.1:4711"
+	req.Header.Set("X-Forwarded-For", "203.0.113.5")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Real-Ip", "1.2.3.4")
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, req)
+
+	if want := "203.0.113.5 https wab.example"; resp.Body.String() != want {
+		t.Errorf("got %q, want %q", resp.Body.String(), want)
+	}
+}
@@ -0,0 +1,203 @@
+package trustedproxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// v2Signature starts every PROXY protocol v2 header.
+var v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// v1MaxLength is the longest v1 header, CRLF included.
+const v1MaxLength = 107
+
+// Listener expects a PROXY protocol header, v1 or v2, in front of every
+// connection from a trusted proxy and uses its source as the connection's
+// RemoteAddr. Connections from anyone else are passed through untouched. The
+// header is read on first use in the connection's own goroutine, it has to
+// arrive within timeout.
+func (t *Trusted) Listener(inner net.Listener, timeout time.Duration) net.Listener {
+	return &listener{Listener: inner, trusted: t, timeout: timeout}
+}
+
+type listener struct {
+	net.Listener
+	trusted *Trusted
+	timeout time.Duration
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return &proxyConn{Conn: conn, trusted: l.trusted, timeout: l.timeout}, nil
+}
+
+type proxyConn struct {
+	net.Conn
+	trusted *Trusted
+	timeout time.Duration
+
+	once   sync.Once
+	reader *bufio.Reader
+	remote net.Addr
+	err    error
+}
+
+func (c *proxyConn) init() {
+	c.once.Do(func() {
+		c.remote = c.Conn.RemoteAddr()
+		if !c.trusted.Contains(addrIP(c.remote.String())) {
+			return
+		}
+
+		c.reader = bufio.NewReader(c.Conn)
+
+		_ = c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+		source, err := readHeader(c.reader)
+		_ = c.Conn.SetReadDeadline(time.Time{})
+
+		if err != nil {
+			c.err = fmt.Errorf("reading PROXY protocol header from %s: %w", c.remote, err)
+			return
+		}
+
+		// LOCAL and UNKNOWN headers are the proxy talking for itself.
+		if source != nil {
+			c.remote = source
+		}
+	})
+}
+
+func (c *proxyConn) Read(b []byte) (int, error) {
+	c.init()
+
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	if c.reader != nil {
+		return c.reader.Read(b)
+	}
+
+	return c.Conn.Read(b)
+}
+
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.init()
+
+	return c.remote
+}
+
+// readHeader reads a v1 or v2 header, the source is nil when the header
+// doesn't carry one.
+func readHeader(reader *bufio.Reader) (net.Addr, error) {
+	start, err := reader.Peek(len(v2Signature))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.Equal(start, v2Signature):
+		return readV2(reader)
+	case bytes.HasPrefix(start, []byte("PROXY ")):
+		return readV1(reader)
+	}
+
+	return nil, errors.New("no PROXY protocol header")
+}
+
+// readV1 reads a header like "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\n".
+func readV1(reader *bufio.Reader) (net.Addr, error) {
+	var line []byte
+
+	for len(line) < v1MaxLength {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+
+	text, ok := strings.CutSuffix(string(line), "\r\n")
+	if !ok {
+		return nil, errors.New("malformed v1 header")
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("malformed v1 header %q", text)
+	}
+
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+
+	if ip == nil || err != nil {
+		return nil, fmt.Errorf("malformed v1 header %q", text)
+	}
+
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readV2 reads the binary header: the signature, version and command,
+// address family, length and the addresses.
+func readV2(reader *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, len(v2Signature)+4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+
+	versionCommand, family := header[12], header[13]
+	length := binary.BigEndian.Uint16(header[14:16])
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, err
+	}
+
+	if versionCommand>>4 != 2 {
+		return nil, fmt.Errorf("unsupported version %d", versionCommand>>4)
+	}
+
+	// Command 0 is LOCAL, the proxy's own health checks.
+	if versionCommand&0x0f == 0 {
+		return nil, nil
+	}
+
+	switch family >> 4 {
+	case 1: // AF_INET: source and destination address, then their ports.
+		if len(payload) < 12 {
+			return nil, errors.New("short v2 IPv4 address block")
+		}
+
+		return &net.TCPAddr{IP: net.IP(payload[0:4]), Port: int(binary.BigEndian.Uint16(payload[8:10]))}, nil
+	case 2: // AF_INET6
+		if len(payload) < 36 {
+			return nil, errors.New("short v2 IPv6 address block")
+		}
+
+		return &net.TCPAddr{IP: net.IP(payload[0:16]), Port: int(binary.BigEndian.Uint16(payload[32:34]))}, nil
+	}
+
+	// AF_UNSPEC and AF_UNIX don't have an address we could use.
+	return nil, nil
+}
@@ -0,0 +1,162 @@
+package trustedproxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// v2Header builds a v2 header with command (0 LOCAL, 1 PROXY), family and
+// the address block.
+func v2Header(version, command, family byte, addresses []byte) []byte {
+	header := append([]byte{}, v2Signature...)
+	header = append(header, version<<4|command, family, 0, 0)
+	binary.BigEndian.PutUint16(header[14:], uint16(len(addresses)))
+
+	return append(header, addresses...)
+}
+
+func v2IPv4(src, dst string, srcPort, dstPort uint16) []byte {
+	block := append(net.ParseIP(src).To4(), net.ParseIP(dst).To4()...)
+	block = binary.BigEndian.AppendUint16(block, srcPort)
+
+	return binary.BigEndian.AppendUint16(block, dstPort)
+}
+
+func v2IPv6(src, dst string, srcPort, dstPort uint16) []byte {
+	block := append(net.ParseIP(src).To16(), net.ParseIP(dst).To16()...)
+	block = binary.BigEndian.AppendUint16(block, srcPort)
+
+	return binary.BigEndian.AppendUint16(block, dstPort)
+}
+
+func TestReadHeader(t *testing.T) {
+	cases := []struct {
+		name   string
+		header []byte
+		source string
+		err    bool
+	}{
+		{name: "v1 TCP4", header: []byte("PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\n"), source: "192.0.2.1:56324"},
+		{name: "v1 TCP6", header: []byte("PROXY TCP6 2001:db8::1 2001:db8::2 4711 443\r\n"), source: "[2001:db8::1]:4711"},
+		{name: "v1 UNKNOWN", header: []byte("PROXY UNKNOWN\r\n")},
+		{name: "v1 UNKNOWN with addresses", header: []byte("PROXY UNKNOWN ffff::1 ffff::2 1 2\r\n")},
+		{name: "v1 without CRLF", header: []byte("PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\n"), err: true},
+		{name: "v1 missing fields", header: []byte("PROXY TCP4 192.0.2.1 192.0.2.2 56324\r\n"), err: true},
+		{name: "v1 bad protocol", header: []byte("PROXY UDP4 192.0.2.1 192.0.2.2 56324 443\r\n"), err: true},
+		{name: "v1 bad address", header: []byte("PROXY TCP4 192.0.2.x 192.0.2.2 56324 443\r\n"), err: true},
+		{name: "v1 bad port", header: []byte("PROXY TCP4 192.0.2.1 192.0.2.2 65536 443\r\n"), err: true},
+		{name: "v1 too long", header: []byte("PROXY TCP4 " + strings.Repeat("1", 120) + "\r\n"), err: true},
+		{name: "v1 truncated", header: []byte("PROXY TCP4 192.0.2.1"), err: true},
+		{name: "v2 IPv4", header: v2Header(2, 1, 0x11, v2IPv4("192.0.2.1", "192.0.2.2", 56324, 443)), source: "192.0.2.1:56324"},
+		{name: "v2 IPv6", header: v2Header(2, 1, 0x21, v2IPv6("2001:db8::1", "2001:db8::2", 4711, 443)), source: "[2001:db8::1]:4711"},
+		{name: "v2 LOCAL", header: v2Header(2, 0, 0x11, v2IPv4("192.0.2.1", "192.0.2.2", 56324, 443))},
+		{name: "v2 AF_UNSPEC", header: v2Header(2, 1, 0x00, nil)},
+		{name: "v2 TLVs after the addresses", header: v2Header(2, 1, 0x11, append(v2IPv4("192.0.2.1", "192.0.2.2", 1, 2), 0x04, 0, 1, 0)), source: "192.0.2.1:1"},
+		{name: "v2 bad version", header: v2Header(1, 1, 0x11, v2IPv4("192.0.2.1", "192.0.2.2", 1, 2)), err: true},
+		{name: "v2 short IPv4 block", header: v2Header(2, 1, 0x11, []byte{192, 0, 2, 1}), err: true},
+		{name: "v2 short IPv6 block", header: v2Header(2, 1, 0x21, v2IPv4("192.0.2.1", "192.0.2.2", 1, 2)), err: true},
+		{name: "v2 truncated", header: v2Header(2, 1, 0x11, v2IPv4("192.0.2.1", "192.0.2.2", 1, 2))[:20], err: true},
+		{name: "no header", header: []byte("GET / HTTP/1.1\r\nHost: wab\r\n\r\n"), err: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := bufio.NewReader(bytes.NewReader(append(tc.header, "GET /"...)))
+
+			source, err := readHeader(reader)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("got source %v, want an error", source)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("got error %v", err)
+			}
+
+			if got := addrString(source); got != tc.source {
+				t.Errorf("got source %q, want %q", got, tc.source)
+			}
+
+			// The header is consumed, the request follows.
+			if rest, _ := reader.Peek(5); string(rest) != "GET /" {
+				t.Errorf("got %q after the header", rest)
+			}
+		})
+	}
+}
+
+func addrString(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+
+	return addr.String()
+}
+
+func TestListener(t *testing.T) {
+	cases := []struct {
+		name    string
+		trusted []string
+		send    string
+		remote  string
+		data    string
+	}{
+		{name: "trusted proxy", trusted: []string{"127.0.0.1"}, send: "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\nhello", remote: "192.0.2.1:56324", data: "hello"},
+		{name: "untrusted peer", send: "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\nhello", data: "PROXY"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			trusted, err := Parse(tc.trusted)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			inner, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lis := trusted.Listener(inner, time.Second)
+			defer lis.Close()
+
+			client, err := net.Dial("tcp", inner.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			if _, err := client.Write([]byte(tc.send)); err != nil {
+				t.Fatal(err)
+			}
+
+			conn, err := lis.Accept()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			remote := tc.remote
+			if remote == "" {
+				remote = client.LocalAddr().String()
+			}
+
+			if got := conn.RemoteAddr().String(); got != remote {
+				t.Errorf("got RemoteAddr %s, want %s", got, remote)
+			}
+
+			data := make([]byte, len(tc.data))
+			if _, err := conn.Read(data); err != nil || string(data) != tc.data {
+				t.Errorf("got %q (%v), want %q", data, err, tc.data)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// Package trustedproxy works out who a client really is when wab sits
+// behind reverse proxies like traefik or envoy. Only the proxies listed as
+// trusted get to say so, through the Forwarded and X-Forwarded-* headers or a
+// PROXY protocol header in front of the connection. Everyone else is taken at
+// their socket address and their forwarding headers are dropped.
+package trustedproxy
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Trusted is a set of proxy addresses.
+type Trusted struct {
+	nets []*net.IPNet
+}
+
+// Parse builds the set from CIDRs (10.0.0.0/8) and single addresses
+// (127.0.0.1, ::1).
+func Parse(proxies []string) (*Trusted, error) {
+	t := &Trusted{}
+
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q, expected an IP address or a CIDR", proxy)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+
+			t.nets = append(t.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+
+			continue
+		}
+
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+
+		t.nets = append(t.nets, ipNet)
+	}
+
+	return t, nil
+}
+
+// Empty reports whether no proxy is trusted.
+func (t *Trusted) Empty() bool {
+	return len(t.nets) == 0
+}
+
+// Contains reports whether ip belongs to a trusted proxy.
+func (t *Trusted) Contains(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, ipNet := range t.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addrIP is the IP of a net.Addr or host:port string, nil when there isn't
+// one.
+func addrIP(addr string) net.IP {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	return net.ParseIP(host)
+}
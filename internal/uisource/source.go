@@ -0,0 +1,155 @@
+// Package uisource picks where the Vue app comes from: a dev server wab
+// proxies to, a directory on disk, a zip bundle or the files embedded at build
+// time, in that order. The directory and the bundle ship UI fixes without
+// rebuilding wab.
+package uisource
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"time"
+)
+
+// The kinds of Source.
+const (
+	KindProxy    = "proxy"
+	KindDir      = "dir"
+	KindBundle   = "bundle"
+	KindEmbedded = "embedded"
+	KindNone     = "none"
+)
+
+// Source is the active UI.
+type Source struct {
+	Kind string `json:"source"`
+	// Path is the proxied URL, the directory or the bundle, empty for the
+	// embedded files.
+	Path string `json:"path,omitempty"`
+	// FS holds index.html and the assets, nil for KindProxy and KindNone.
+	FS fs.FS `json:"-"`
+}
+
+// Open picks the source: proxy when set, then dir, then bundle, then embedded
+// (which is nil in noui builds). Only one of proxy, dir and bundle can be set.
+func Open(proxy, dir, bundle string, embedded fs.FS) (*Source, error) {
+	set := 0
+	for _, option := range []string{proxy, dir, bundle} {
+		if option != "" {
+			set++
+		}
+	}
+
+	switch {
+	case set > 1:
+		return nil, errors.New("only one of the UI proxy, directory and bundle can be set")
+	case proxy != "":
+		target, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("parsing UI proxy: %w", err)
+		}
+
+		if (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return nil, fmt.Errorf("parsing UI proxy: %s is not an http(s) URL", proxy)
+		}
+
+		return &Source{Kind: KindProxy, Path: proxy}, nil
+	case dir != "":
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("opening UI directory: %w", err)
+		}
+
+		if !info.IsDir() {
+			return nil, fmt.Errorf("opening UI directory: %s is not a directory", dir)
+		}
+
+		return &Source{Kind: KindDir, Path: dir, FS: os.DirFS(dir)}, nil
+	case bundle != "":
+		reader, err := zip.OpenReader(bundle)
+		if err != nil {
+			return nil, fmt.Errorf("opening UI bundle: %w", err)
+		}
+
+		fsys, err := root(reader)
+		if err != nil {
+			return nil, fmt.Errorf("opening UI bundle %s: %w", bundle, err)
+		}
+
+		return &Source{Kind: KindBundle, Path: bundle, FS: fsys}, nil
+	case embedded != nil:
+		return &Source{Kind: KindEmbedded, FS: embedded}, nil
+	}
+
+	return &Source{Kind: KindNone}, nil
+}
+
+// root is the directory of a bundle holding index.html, the bundle itself or
+// a dist folder in it, so both zip -r ui.zip dist and zipping the contents of
+// dist work.
+func root(fsys fs.FS) (fs.FS, error) {
+	if _, err := fs.Stat(fsys, "index.html"); err == nil {
+		return fsys, nil
+	}
+
+	if _, err := fs.Stat(fsys, "dist/index.html"); err == nil {
+		return fs.Sub(fsys, "dist")
+	}
+
+	return nil, errors.New("no index.html or dist/index.html in the bundle")
+}
+
+func (s *Source) String() string {
+	if s.Path == "" {
+		return s.Kind
+	}
+
+	return s.Kind + " " + s.Path
+}
+
+// Watch polls a directory source every interval and calls onChange when a
+// file was added, removed or modified. It never returns, other kinds don't
+// change and return right away.
+func (s *Source) Watch(interval time.Duration, onChange func()) {
+	if s.Kind != KindDir {
+		return
+	}
+
+	last := s.fingerprint()
+
+	for range time.Tick(interval) {
+		current := s.fingerprint()
+		if current != last {
+			last = current
+
+			onChange()
+		}
+	}
+}
+
+// fingerprint hashes the name, size and modification time of every file.
+func (s *Source) fingerprint() string {
+	hash := sha256.New()
+
+	_ = fs.WalkDir(s.FS, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+
+		fmt.Fprintf(hash, "%s %d %d\n", name, info.Size(), info.ModTime().UnixNano())
+
+		return nil
+	})
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
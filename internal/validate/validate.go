@@ -0,0 +1,238 @@
+// Package validate enforces the field constraints declared with the
+// (wab.validate.rules) option in the proto files. Nothing here is specific to a
+// service, the rules are read straight from the message descriptors so any
+// annotated message can be checked.
+package validate
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/fernferret/wab/gen/validatepb"
+	"github.com/fernferret/wab/internal/apierr"
+)
+
+// patterns caches compiled regular expressions so a pattern is only compiled
+// the first time it's seen.
+var patterns sync.Map
+
+// Message checks msg against the rules declared on its fields (and the fields
+// of any nested messages). If one or more fields are invalid, an
+// InvalidArgument error is returned with a google.rpc.BadRequest detail
+// listing every violation, not just the first one.
+func Message(msg proto.Message) error {
+	violations, err := collect(msg.ProtoReflect(), "", nil)
+	if err != nil {
+		return apierr.New(codes.Internal, apierr.ReasonInternal, err.Error())
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	msgText := fmt.Sprintf("invalid request, %d field violation(s)", len(violations))
+
+	return apierr.BadRequest(apierr.ReasonInvalidRequest, msgText, violations...)
+}
+
+// UnaryServerInterceptor validates every unary request before it's handed to
+// the service implementation.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if msg, ok := req.(proto.Message); ok {
+			if err := Message(msg); err != nil {
+				return nil, err
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor validates every message received on a stream,
+// including the single request of a server-streaming call.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingStream{ServerStream: ss})
+	}
+}
+
+type validatingStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatingStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	if msg, ok := m.(proto.Message); ok {
+		return Message(msg)
+	}
+
+	return nil
+}
+
+func collect(msg protoreflect.Message, prefix string, violations []*errdetails.BadRequest_FieldViolation) ([]*errdetails.BadRequest_FieldViolation, error) {
+	fields := msg.Descriptor().Fields()
+
+	for idx := 0; idx < fields.Len(); idx++ {
+		fd := fields.Get(idx)
+		path := prefix + string(fd.Name())
+
+		if rules := Rules(fd); rules != nil {
+			desc, err := check(msg, fd, rules)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+
+			if desc != "" {
+				violations = append(violations, apierr.Violation(path, desc))
+			}
+		}
+
+		// Walk into nested messages so their rules are enforced too.
+		if fd.Kind() != protoreflect.MessageKind && fd.Kind() != protoreflect.GroupKind || fd.IsMap() || !msg.Has(fd) {
+			continue
+		}
+
+		var err error
+
+		if fd.IsList() {
+			list := msg.Get(fd).List()
+			for item := 0; item < list.Len(); item++ {
+				violations, err = collect(list.Get(item).Message(), fmt.Sprintf("%s[%d].", path, item), violations)
+				if err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			violations, err = collect(msg.Get(fd).Message(), path+".", violations)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return violations, nil
+}
+
+// Rules returns the (wab.validate.rules) option of fd, or nil when the field
+// has no constraints.
+func Rules(fd protoreflect.FieldDescriptor) *validatepb.FieldRules {
+	opts, ok := fd.Options().(*descriptorpb.FieldOptions)
+	if !ok || opts == nil || !proto.HasExtension(opts, validatepb.E_Rules) {
+		return nil
+	}
+
+	rules, _ := proto.GetExtension(opts, validatepb.E_Rules).(*validatepb.FieldRules)
+
+	return rules
+}
+
+// check returns a human readable description of the first rule fd breaks, or
+// an empty string if the field is valid. Repeated and map fields only honour
+// the required rule.
+func check(msg protoreflect.Message, fd protoreflect.FieldDescriptor, rules *validatepb.FieldRules) (string, error) {
+	if rules.GetRequired() && !msg.Has(fd) {
+		return "is required", nil
+	}
+
+	if fd.IsList() || fd.IsMap() {
+		return "", nil
+	}
+
+	val := msg.Get(fd)
+
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		str := val.String()
+		if desc := checkLength(utf8.RuneCountInString(str), "characters", rules); desc != "" {
+			return desc, nil
+		}
+
+		// An empty optional string is let through so "not set" doesn't have to
+		// match the pattern.
+		if rules.GetPattern() == "" || !msg.Has(fd) {
+			return "", nil
+		}
+
+		re, err := compile(rules.GetPattern())
+		if err != nil {
+			return "", err
+		}
+
+		if !re.MatchString(str) {
+			return fmt.Sprintf("must match the pattern %q", rules.GetPattern()), nil
+		}
+	case protoreflect.BytesKind:
+		return checkLength(len(val.Bytes()), "bytes", rules), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		num := val.Int()
+		if rules.Min != nil && num < rules.GetMin() {
+			return fmt.Sprintf("must be at least %d", rules.GetMin()), nil
+		}
+
+		if rules.Max != nil && num > rules.GetMax() {
+			return fmt.Sprintf("must be at most %d", rules.GetMax()), nil
+		}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		num := val.Uint()
+		if rules.Min != nil && rules.GetMin() > 0 && num < uint64(rules.GetMin()) {
+			return fmt.Sprintf("must be at least %d", rules.GetMin()), nil
+		}
+
+		if rules.Max != nil && (rules.GetMax() < 0 || num > uint64(rules.GetMax())) {
+			return fmt.Sprintf("must be at most %d", rules.GetMax()), nil
+		}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		num := val.Float()
+		if rules.Min != nil && num < float64(rules.GetMin()) {
+			return fmt.Sprintf("must be at least %d", rules.GetMin()), nil
+		}
+
+		if rules.Max != nil && num > float64(rules.GetMax()) {
+			return fmt.Sprintf("must be at most %d", rules.GetMax()), nil
+		}
+	}
+
+	return "", nil
+}
+
+func checkLength(length int, unit string, rules *validatepb.FieldRules) string {
+	if rules.MinLen != nil && length < int(rules.GetMinLen()) {
+		return fmt.Sprintf("must be at least %d %s long", rules.GetMinLen(), unit)
+	}
+
+	if rules.MaxLen != nil && length > int(rules.GetMaxLen()) {
+		return fmt.Sprintf("must be at most %d %s long", rules.GetMaxLen(), unit)
+	}
+
+	return ""
+}
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validation pattern %q: %w", pattern, err)
+	}
+
+	patterns.Store(pattern, re)
+
+	return re, nil
+}
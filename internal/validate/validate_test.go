@@ -0,0 +1,271 @@
+package validate
+
+import (
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/fernferret/wab/gen/validatepb"
+)
+
+// testMessage builds a message with one field per kind of rule, so the rules
+// can be tested without adding them to the real API.
+func testMessage(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+
+	field := func(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type, rules *validatepb.FieldRules) *descriptorpb.FieldDescriptorProto {
+		opts := &descriptorpb.FieldOptions{}
+		proto.SetExtension(opts, validatepb.E_Rules, rules)
+
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     kind.Enum(),
+			Options:  opts,
+		}
+	}
+
+	child := field("child", 8, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, &validatepb.FieldRules{})
+	child.TypeName = proto.String(".wab.validate.test.Sample")
+
+	tags := field("tags", 9, descriptorpb.FieldDescriptorProto_TYPE_STRING, &validatepb.FieldRules{Required: true})
+	tags.Label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("validate_test.proto"),
+		Package:    proto.String("wab.validate.test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{validatepb.File_validate_proto.Path()},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Sample"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, &validatepb.FieldRules{MinLen: proto.Uint32(2), MaxLen: proto.Uint32(5)}),
+				field("code", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, &validatepb.FieldRules{Pattern: "^[a-z]+$"}),
+				field("id", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, &validatepb.FieldRules{Required: true, Pattern: "^[a-z]+$"}),
+				field("count", 4, descriptorpb.FieldDescriptorProto_TYPE_INT32, &validatepb.FieldRules{Min: proto.Int64(-1), Max: proto.Int64(10)}),
+				field("qty", 5, descriptorpb.FieldDescriptorProto_TYPE_UINT32, &validatepb.FieldRules{Min: proto.Int64(1), Max: proto.Int64(3)}),
+				field("data", 6, descriptorpb.FieldDescriptorProto_TYPE_BYTES, &validatepb.FieldRules{MaxLen: proto.Uint32(2)}),
+				field("ratio", 7, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, &validatepb.FieldRules{Min: proto.Int64(0), Max: proto.Int64(1)}),
+				child,
+				tags,
+			},
+		}},
+	}
+
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("failed to build the test descriptor: %v", err)
+	}
+
+	return fd.Messages().Get(0)
+}
+
+func TestMessage(t *testing.T) {
+	md := testMessage(t)
+
+	// valid sets the fields needed to pass, every case changes one thing.
+	valid := func(msg *dynamicpb.Message) {
+		msg.Set(md.Fields().ByName("name"), protoreflect.ValueOfString("ab"))
+		msg.Set(md.Fields().ByName("id"), protoreflect.ValueOfString("abc"))
+		msg.Set(md.Fields().ByName("qty"), protoreflect.ValueOfUint32(1))
+
+		tags := msg.Mutable(md.Fields().ByName("tags")).List()
+		tags.Append(protoreflect.ValueOfString("a"))
+	}
+
+	cases := []struct {
+		name   string
+		set    func(msg *dynamicpb.Message)
+		fields map[string]string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name: "string too short",
+			set: func(msg *dynamicpb.Message) {
+				msg.Set(md.Fields().ByName("name"), protoreflect.ValueOfString("a"))
+			},
+			fields: map[string]string{"name": "must be at least 2 characters long"},
+		},
+		{
+			name: "unset string still has a length",
+			set: func(msg *dynamicpb.Message) {
+				msg.Clear(md.Fields().ByName("name"))
+			},
+			fields: map[string]string{"name": "must be at least 2 characters long"},
+		},
+		{
+			name: "multibyte characters count once",
+			set: func(msg *dynamicpb.Message) {
+				msg.Set(md.Fields().ByName("name"), protoreflect.ValueOfString("ééééé"))
+			},
+		},
+		{
+			name: "string too long",
+			set: func(msg *dynamicpb.Message) {
+				msg.Set(md.Fields().ByName("name"), protoreflect.ValueOfString("abcdef"))
+			},
+			fields: map[string]string{"name": "must be at most 5 characters long"},
+		},
+		{
+			name: "pattern mismatch",
+			set: func(msg *dynamicpb.Message) {
+				msg.Set(md.Fields().ByName("code"), protoreflect.ValueOfString("ABC"))
+			},
+			fields: map[string]string{"code": `must match the pattern "^[a-z]+$"`},
+		},
+		{
+			name: "unset string skips the pattern",
+			set: func(msg *dynamicpb.Message) {
+				msg.Clear(md.Fields().ByName("code"))
+			},
+		},
+		{
+			name: "required string unset",
+			set: func(msg *dynamicpb.Message) {
+				msg.Clear(md.Fields().ByName("id"))
+			},
+			fields: map[string]string{"id": "is required"},
+		},
+		{
+			name: "signed below min",
+			set: func(msg *dynamicpb.Message) {
+				msg.Set(md.Fields().ByName("count"), protoreflect.ValueOfInt32(-2))
+			},
+			fields: map[string]string{"count": "must be at least -1"},
+		},
+		{
+			name: "signed above max",
+			set: func(msg *dynamicpb.Message) {
+				msg.Set(md.Fields().ByName("count"), protoreflect.ValueOfInt32(11))
+			},
+			fields: map[string]string{"count": "must be at most 10"},
+		},
+		{
+			name: "unsigned below min",
+			set: func(msg *dynamicpb.Message) {
+				msg.Clear(md.Fields().ByName("qty"))
+			},
+			fields: map[string]string{"qty": "must be at least 1"},
+		},
+		{
+			name: "unsigned above max",
+			set: func(msg *dynamicpb.Message) {
+				msg.Set(md.Fields().ByName("qty"), protoreflect.ValueOfUint32(4))
+			},
+			fields: map[string]string{"qty": "must be at most 3"},
+		},
+		{
+			name: "bytes too long",
+			set: func(msg *dynamicpb.Message) {
+				msg.Set(md.Fields().ByName("data"), protoreflect.ValueOfBytes([]byte("abc")))
+			},
+			fields: map[string]string{"data": "must be at most 2 bytes long"},
+		},
+		{
+			name: "float above max",
+			set: func(msg *dynamicpb.Message) {
+				msg.Set(md.Fields().ByName("ratio"), protoreflect.ValueOfFloat64(1.5))
+			},
+			fields: map[string]string{"ratio": "must be at most 1"},
+		},
+		{
+			name: "required list empty",
+			set: func(msg *dynamicpb.Message) {
+				msg.Clear(md.Fields().ByName("tags"))
+			},
+			fields: map[string]string{"tags": "is required"},
+		},
+		{
+			name: "nested message fields are prefixed",
+			set: func(msg *dynamicpb.Message) {
+				child := dynamicpb.NewMessage(md)
+				valid(child)
+				child.Set(md.Fields().ByName("name"), protoreflect.ValueOfString("a"))
+				msg.Set(md.Fields().ByName("child"), protoreflect.ValueOfMessage(child))
+			},
+			fields: map[string]string{"child.name": "must be at least 2 characters long"},
+		},
+		{
+			name: "every violation is listed",
+			set: func(msg *dynamicpb.Message) {
+				msg.Clear(md.Fields().ByName("id"))
+				msg.Set(md.Fields().ByName("qty"), protoreflect.ValueOfUint32(9))
+			},
+			fields: map[string]string{"id": "is required", "qty": "must be at most 3"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := dynamicpb.NewMessage(md)
+			valid(msg)
+
+			if tc.set != nil {
+				tc.set(msg)
+			}
+
+			err := Message(msg)
+			if len(tc.fields) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				return
+			}
+
+			st, _ := status.FromError(err)
+			if st.Code() != codes.InvalidArgument {
+				t.Fatalf("expected InvalidArgument, got %v", err)
+			}
+
+			got := map[string]string{}
+
+			for _, detail := range st.Details() {
+				if br, ok := detail.(*errdetails.BadRequest); ok {
+					for _, violation := range br.GetFieldViolations() {
+						got[violation.GetField()] = violation.GetDescription()
+					}
+				}
+			}
+
+			if len(got) != len(tc.fields) {
+				t.Errorf("got violations %v, want %v", got, tc.fields)
+			}
+
+			for field, want := range tc.fields {
+				if got[field] != want {
+					t.Errorf("field %s: got %q, want %q", field, got[field], want)
+				}
+			}
+		})
+	}
+}
+
+func TestMessageInvalidPattern(t *testing.T) {
+	md := testMessage(t)
+	fd := md.Fields().ByName("code")
+
+	if got := Rules(fd).GetPattern(); got != "^[a-z]+$" {
+		t.Fatalf("Rules returned the pattern %q", got)
+	}
+
+	msg := dynamicpb.NewMessage(md)
+	msg.Set(fd, protoreflect.ValueOfString("abc"))
+
+	// A bad pattern is a bug in the proto file, not in the request.
+	if desc, err := check(msg, fd, &validatepb.FieldRules{Pattern: "("}); err == nil {
+		t.Errorf("expected an error for an invalid pattern, got description %q", desc)
+	}
+}
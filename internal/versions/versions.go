@@ -0,0 +1,174 @@
+// Package versions serves several versions of one API side by side. Every
+// version is registered as its own gRPC service, so reflection and grpcui
+// list them separately, and every call is counted per version and method to
+// show when an old version is safe to retire.
+package versions
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Version is one version of an API.
+type Version struct {
+	// Name is the version label, like "v1".
+	Name string
+	// Desc and Impl are what the generated Register<Service>Server function
+	// would pass to RegisterService.
+	Desc *grpc.ServiceDesc
+	Impl interface{}
+	// Deprecated marks versions that clients should move off of. It is only
+	// reported, calls still work.
+	Deprecated bool
+}
+
+// Set is every version of one API.
+type Set struct {
+	api      string
+	versions []*version
+}
+
+type version struct {
+	Version
+
+	counted  *grpc.ServiceDesc
+	calls    atomic.Uint64
+	methods  map[string]*atomic.Uint64
+	lastCall atomic.Int64
+}
+
+// NewSet builds the set for api, an informal name like "wab.greeter".
+func NewSet(api string, versions ...Version) *Set {
+	set := &Set{api: api}
+
+	for _, v := range versions {
+		set.versions = append(set.versions, newVersion(v))
+	}
+
+	return set
+}
+
+func newVersion(v Version) *version {
+	ver := &version{
+		Version: v,
+		methods: map[string]*atomic.Uint64{},
+	}
+
+	counted := *v.Desc
+	counted.Methods = make([]grpc.MethodDesc, len(v.Desc.Methods))
+	counted.Streams = make([]grpc.StreamDesc, len(v.Desc.Streams))
+
+	for idx, method := range v.Desc.Methods {
+		counter, handler := ver.counter(method.MethodName), method.Handler
+
+		method.Handler = func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			ver.record(counter)
+
+			return handler(srv, ctx, dec, interceptor)
+		}
+
+		counted.Methods[idx] = method
+	}
+
+	for idx, stream := range v.Desc.Streams {
+		counter, handler := ver.counter(stream.StreamName), stream.Handler
+
+		stream.Handler = func(srv interface{}, ss grpc.ServerStream) error {
+			ver.record(counter)
+
+			return handler(srv, ss)
+		}
+
+		counted.Streams[idx] = stream
+	}
+
+	ver.counted = &counted
+
+	return ver
+}
+
+func (v *version) counter(method string) *atomic.Uint64 {
+	counter := &atomic.Uint64{}
+	v.methods[method] = counter
+
+	return counter
+}
+
+func (v *version) record(counter *atomic.Uint64) {
+	counter.Add(1)
+	v.calls.Add(1)
+	v.lastCall.Store(time.Now().UnixNano())
+}
+
+// API is the name the set was created with.
+func (s *Set) API() string {
+	return s.api
+}
+
+// Register registers every version with each registrar, usually the
+// grpc.Server and the in-process channel. Calls are counted no matter which
+// registrar they arrive through.
+func (s *Set) Register(registrars ...grpc.ServiceRegistrar) {
+	for _, registrar := range registrars {
+		for _, v := range s.versions {
+			registrar.RegisterService(v.counted, v.Impl)
+		}
+	}
+}
+
+// ServiceDesc returns the counting service description of the version
+// serving service, for registering it under another name. It returns nil if
+// no version serves service.
+func (s *Set) ServiceDesc(service string) (*grpc.ServiceDesc, interface{}) {
+	for _, v := range s.versions {
+		if v.Desc.ServiceName == service {
+			return v.counted, v.Impl
+		}
+	}
+
+	return nil, nil
+}
+
+// Usage is a snapshot of the calls made to one version.
+type Usage struct {
+	API        string            `json:"api"`
+	Version    string            `json:"version"`
+	Service    string            `json:"service"`
+	Deprecated bool              `json:"deprecated,omitempty"`
+	Calls      uint64            `json:"calls"`
+	Methods    map[string]uint64 `json:"methods"`
+	LastCall   *time.Time        `json:"last_call,omitempty"`
+}
+
+// Usage returns the counters of every version, in the order the versions
+// were given to NewSet.
+func (s *Set) Usage() []Usage {
+	usage := make([]Usage, 0, len(s.versions))
+
+	for _, v := range s.versions {
+		u := Usage{
+			API:        s.api,
+			Version:    v.Name,
+			Service:    v.Desc.ServiceName,
+			Deprecated: v.Deprecated,
+			Calls:      v.calls.Load(),
+			Methods:    make(map[string]uint64, len(v.methods)),
+		}
+
+		for name, counter := range v.methods {
+			u.Methods[name] = counter.Load()
+		}
+
+		if last := v.lastCall.Load(); last != 0 {
+			at := time.Unix(0, last)
+			u.LastCall = &at
+		}
+
+		usage = append(usage, u)
+	}
+
+	return usage
+}
@@ -0,0 +1,41 @@
+package wabmw
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// BasePath strips prefix (like /tools/wab) from every request before routing,
+// so the routes stay at the root while wab is mounted under a reverse proxy's
+// sub-path. The prefix itself redirects to prefix/, anything outside of it is
+// a 404. Register it with echo.Pre.
+func BasePath(prefix string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ectx echo.Context) error {
+			req := ectx.Request()
+
+			if req.URL.Path == prefix {
+				target := prefix + "/"
+				if req.URL.RawQuery != "" {
+					target += "?" + req.URL.RawQuery
+				}
+
+				return ectx.Redirect(http.StatusMovedPermanently, target)
+			}
+
+			rest, ok := strings.CutPrefix(req.URL.Path, prefix+"/")
+			if !ok {
+				return echo.ErrNotFound
+			}
+
+			req.URL.Path = "/" + rest
+			if req.URL.RawPath != "" {
+				req.URL.RawPath = "/" + strings.TrimPrefix(req.URL.RawPath, prefix+"/")
+			}
+
+			return next(ectx)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package wabmw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestBasePath(t *testing.T) {
+	e := echo.New()
+	e.Pre(BasePath("/tools/wab"))
+	e.GET("/*", func(ectx echo.Context) error {
+		return ectx.String(http.StatusOK, ectx.Request().URL.Path+" "+ectx.Request().URL.RawPath)
+	})
+
+	cases := []struct {
+		name     string
+		target   string
+		status   int
+		body     string
+		location string
+	}{
+		{name: "root", target: "/tools/wab/", status: http.StatusOK, body: "/ "},
+		{name: "route", target: "/tools/wab/api/v1/greet?name=bob", status: http.StatusOK, body: "/api/v1/greet "},
+		{name: "escaped path", target: "/tools/wab/files/a%2Fb", status: http.StatusOK, body: "/files/a/b /files/a%2Fb"},
+		{name: "prefix redirects", target: "/tools/wab", status: http.StatusMovedPermanently, location: "/tools/wab/"},
+		{name: "redirect keeps the query", target: "/tools/wab?tab=docs", status: http.StatusMovedPermanently, location: "/tools/wab/?tab=docs"},
+		{name: "outside the prefix", target: "/api/v1/greet", status: http.StatusNotFound},
+		{name: "prefix of a segment", target: "/tools/wabbit/", status: http.StatusNotFound},
+		{name: "site root", target: "/", status: http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := httptest.NewRecorder()
+			e.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, tc.target, nil))
+
+			if resp.Code != tc.status {
+				t.Fatalf("got status %d, want %d", resp.Code, tc.status)
+			}
+
+			if tc.body != "" && resp.Body.String() != tc.body {
+				t.Errorf("got %q, want %q", resp.Body.String(), tc.body)
+			}
+
+			if got := resp.Header().Get("Location"); got != tc.location {
+				t.Errorf("got Location %q, want %q", got, tc.location)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package wabmw
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// DefaultCSP is a strict policy for the Vite build: scripts only from our
+// origin or carrying the request's nonce. Styles stay inline-friendly because
+// Vue binds style attributes.
+const DefaultCSP = "default-src 'self'; script-src 'self' 'nonce-{nonce}'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; font-src 'self' data:; connect-src 'self'; object-src 'none'; base-uri 'self'; form-action 'self'"
+
+const nonceKey = "csp-nonce"
+
+// SecurityConfig is what SecurityHeaders sends.
+type SecurityConfig struct {
+	// CSP is the Content-Security-Policy, "{nonce}" is replaced with a fresh
+	// nonce on every request. Empty disables the header.
+	CSP string
+	// FrameAncestors is the CSP frame-ancestors source list, like 'none' or
+	// 'self' https://admin.example.com. It's also sent when the CSP is skipped.
+	// Empty allows framing from anywhere.
+	FrameAncestors string
+	// ReferrerPolicy is sent as Referrer-Policy unless empty.
+	ReferrerPolicy string
+	// HSTSMaxAge is sent as Strict-Transport-Security on requests that came in
+	// over HTTPS, directly or through a trusted proxy, 0 disables it.
+	HSTSMaxAge time.Duration
+	// SkipCSP leaves the CSP (but not frame-ancestors) off pages that can't
+	// live with it, like grpcui and its inline scripts.
+	SkipCSP middleware.Skipper
+}
+
+// SecurityHeaders sets the security headers of config on every response. The
+// nonce of the request is available from CSPNonce.
+func SecurityHeaders(config SecurityConfig) echo.MiddlewareFunc {
+	if config.SkipCSP == nil {
+		config.SkipCSP = func(echo.Context) bool { return false }
+	}
+
+	frameAncestors := ""
+	if config.FrameAncestors != "" {
+		frameAncestors = "frame-ancestors " + config.FrameAncestors
+	}
+
+	// X-Frame-Options is the pre-CSP way of saying the same thing, it only
+	// has a value for the two common cases.
+	frameOptions := map[string]string{"'none'": "DENY", "'self'": "SAMEORIGIN"}[config.FrameAncestors]
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ectx echo.Context) error {
+			header := ectx.Response().Header()
+			header.Set("X-Content-Type-Options", "nosniff")
+
+			if frameOptions != "" {
+				header.Set("X-Frame-Options", frameOptions)
+			}
+
+			if config.ReferrerPolicy != "" {
+				header.Set("Referrer-Policy", config.ReferrerPolicy)
+			}
+
+			if config.HSTSMaxAge > 0 && ectx.Scheme() == "https" {
+				header.Set("Strict-Transport-Security", fmt.Sprintf("max-age=%d; includeSubDomains", int(config.HSTSMaxAge.Seconds())))
+			}
+
+			var directives []string
+
+			if config.CSP != "" && !config.SkipCSP(ectx) {
+				nonce, err := newNonce()
+				if err != nil {
+					return err
+				}
+
+				ectx.Set(nonceKey, nonce)
+				directives = append(directives, strings.ReplaceAll(config.CSP, "{nonce}", nonce))
+			}
+
+			if frameAncestors != "" {
+				directives = append(directives, frameAncestors)
+			}
+
+			if len(directives) > 0 {
+				header.Set("Content-Security-Policy", strings.Join(directives, "; "))
+			}
+
+			return next(ectx)
+		}
+	}
+}
+
+// CSPNonce returns the nonce of the request's Content-Security-Policy, or an
+// empty string when there isn't one.
+func CSPNonce(ectx echo.Context) string {
+	nonce, _ := ectx.Get(nonceKey).(string)
+
+	return nonce
+}
+
+// InjectNonce adds nonce to every <script> tag of html, so the scripts of a
+// Vite build are allowed by a nonce-based CSP.
+func InjectNonce(html []byte, nonce string) []byte {
+	if nonce == "" {
+		return html
+	}
+
+	return bytes.ReplaceAll(html, []byte("<script"), []byte(`<script nonce="`+nonce+`"`))
+}
+
+// AllowConnect adds source to the connect-src directive of csp, for APIs the
+// page calls on another origin. A policy without connect-src is returned as
+// is.
+func AllowConnect(csp, source string) string {
+	directives := strings.Split(csp, ";")
+	for idx, directive := range directives {
+		fields := strings.Fields(directive)
+		if len(fields) > 0 && fields[0] == "connect-src" {
+			directives[idx] = " " + strings.Join(append(fields, source), " ")
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(directives, ";"))
+}
+
+func newNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating CSP nonce: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
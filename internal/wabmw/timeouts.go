@@ -0,0 +1,88 @@
+package wabmw
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// TimeoutConfig bounds how long a request may take. The server-wide
+// ReadTimeout and WriteTimeout of net/http cover the whole connection and
+// would cut streams off, these are set per request instead.
+type TimeoutConfig struct {
+	// Read is how long reading the request body may take, 0 is no limit. The
+	// deadline only covers the body, it's cleared once the body is read.
+	Read time.Duration
+	// Write is how long the handler has until the response is written, 0 is
+	// no limit.
+	Write time.Duration
+	// Skipper exempts long-lived requests, like streams and websockets.
+	Skipper middleware.Skipper
+}
+
+// RequestTimeouts sets the read and write deadlines of config on every
+// request the skipper lets through.
+func RequestTimeouts(config TimeoutConfig) echo.MiddlewareFunc {
+	if config.Skipper == nil {
+		config.Skipper = middleware.DefaultSkipper
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ectx echo.Context) error {
+			if config.Skipper(ectx) {
+				return next(ectx)
+			}
+
+			// echo's Response doesn't unwrap, the controller needs the
+			// writer net/http handed out.
+			controller := http.NewResponseController(ectx.Response().Writer)
+			now := time.Now()
+			req := ectx.Request()
+
+			// Once the body is read, net/http watches the connection for the
+			// client going away and cancels the request when that read fails.
+			// A request without a body is watched from the start, a read
+			// deadline would end it once it fires, so only bodies get one and
+			// it's cleared at their end. net/http sets a new read deadline
+			// for the next request on a kept-alive connection.
+			if config.Read > 0 && req.Body != nil && req.Body != http.NoBody {
+				_ = controller.SetReadDeadline(now.Add(config.Read))
+
+				req.Body = &deadlineBody{
+					ReadCloser: req.Body,
+					clear:      func() { _ = controller.SetReadDeadline(time.Time{}) },
+				}
+			}
+
+			// The write deadline stays on a kept-alive connection, it's
+			// cleared so the next request on it, maybe a stream, starts
+			// without it.
+			if config.Write > 0 {
+				_ = controller.SetWriteDeadline(now.Add(config.Write))
+				defer func() { _ = controller.SetWriteDeadline(time.Time{}) }()
+			}
+
+			return next(ectx)
+		}
+	}
+}
+
+// deadlineBody calls clear once the body is read to the end, or fails.
+type deadlineBody struct {
+	io.ReadCloser
+	clear func()
+	once  sync.Once
+}
+
+func (b *deadlineBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.once.Do(b.clear)
+	}
+
+	return n, err
+}
@@ -0,0 +1,111 @@
+package wabmw
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// slowBody sends body after delay.
+type slowBody struct {
+	body  string
+	delay time.Duration
+	sent  bool
+}
+
+func (b *slowBody) Read(p []byte) (int, error) {
+	if b.sent {
+		return 0, io.EOF
+	}
+
+	time.Sleep(b.delay)
+	b.sent = true
+
+	return copy(p, b.body), nil
+}
+
+func TestRequestTimeouts(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	e := echo.New()
+	e.Use(RequestTimeouts(TimeoutConfig{
+		Read:  timeout,
+		Write: 3 * timeout,
+		Skipper: func(ectx echo.Context) bool {
+			return ectx.Request().URL.Path == "/stream"
+		},
+	}))
+
+	// Every handler reads the body, then takes work before answering.
+	handler := func(ectx echo.Context) error {
+		body, err := io.ReadAll(ectx.Request().Body)
+		if err != nil {
+			return ectx.String(http.StatusBadRequest, "read: "+err.Error())
+		}
+
+		work, _ := time.ParseDuration(ectx.QueryParam("work"))
+
+		select {
+		case <-time.After(work):
+		case <-ectx.Request().Context().Done():
+			return ectx.String(http.StatusServiceUnavailable, "cancelled")
+		}
+
+		return ectx.String(http.StatusOK, string(body))
+	}
+	e.Any("/*", handler)
+
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   io.Reader
+		ok     bool
+	}{
+		{name: "fast", method: http.MethodPost, path: "/unary", body: strings.NewReader("hi"), ok: true},
+		{name: "slow handler after the body", method: http.MethodPost, path: "/unary?work=250ms", body: strings.NewReader("hi"), ok: true},
+		{name: "slow handler without a body", method: http.MethodGet, path: "/unary?work=250ms", ok: true},
+		{name: "slow body", method: http.MethodPost, path: "/unary", body: &slowBody{body: "hi", delay: 3 * timeout}},
+		{name: "exempt stream", method: http.MethodGet, path: "/stream?work=500ms", ok: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, server.URL+tc.path, tc.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// A fresh connection each time, a slow body breaks the one it's on.
+			client := &http.Client{Transport: &http.Transport{DialContext: (&net.Dialer{}).DialContext}}
+
+			resp, err := client.Do(req)
+			if err == nil {
+				defer resp.Body.Close()
+
+				body, readErr := io.ReadAll(resp.Body)
+				if readErr != nil || resp.StatusCode != http.StatusOK {
+					err = fmt.Errorf("%s: %s", resp.Status, body)
+				}
+			}
+
+			if tc.ok && err != nil {
+				t.Errorf("got %v, want the request to succeed", err)
+			}
+
+			if !tc.ok && err == nil {
+				t.Errorf("got a response, want the request to fail")
+			}
+		})
+	}
+}
@@ -69,7 +69,7 @@ func ZapMiddleware() echo.MiddlewareFunc {
 			}
 
 			fmtString := fmt.Sprintf("%s %%-%ds %%s", "-->", len(methodColor)-len(req.Method)+padding)
-			zap.S().Infof(fmtString, methodColor, req.URL.Path)
+			zap.S().With("client", ectx.RealIP()).Infof(fmtString, methodColor, req.URL.Path)
 
 			start := time.Now()
 			err := next(ectx)
@@ -0,0 +1,44 @@
+package wab
+
+import (
+	"crypto/tls"
+	"net"
+
+	"github.com/fernferret/wab/internal/connlimit"
+)
+
+// listen opens a listener on address with limits on its connections. It
+// reads the PROXY protocol header of connections from trusted proxies when
+// options ask for it, the per-IP limit counts the clients the headers name.
+// Trusted proxies themselves are exempt from it, without PROXY protocol every
+// client behind one shares its address.
+func listen(address string, options *Options, limits connlimit.Limits) (net.Listener, error) {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	trusted := trustedProxies(options)
+
+	if options.ProxyProtocol {
+		lis = trusted.Listener(lis, proxyHeaderTimeout)
+	}
+
+	limits.Exempt = trusted.Contains
+
+	return limits.Listener(lis), nil
+}
+
+// tlsConfig loads the certificate of options, with HTTP/2 offered the way
+// echo's StartTLS would.
+func tlsConfig(options *Options) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(options.TLSCert, options.TLSKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}, nil
+}
@@ -0,0 +1,101 @@
+package wab
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/fernferret/wab/internal/connlimit"
+)
+
+// TestPerIPLimitBehindProxies opens connections through listen with one
+// connection allowed per address. Each connection sends an optional PROXY
+// protocol header and a line, the server answers with the client it saw.
+func TestPerIPLimitBehindProxies(t *testing.T) {
+	cases := []struct {
+		name    string
+		options *Options
+		// headers are the PROXY protocol headers sent, one connection each,
+		// "" sends none.
+		headers []string
+		// want is the client of every connection, "" when it's turned away.
+		want []string
+	}{
+		{
+			name:    "proxied clients",
+			options: &Options{TrustedProxies: []string{"127.0.0.1"}, ProxyProtocol: true},
+			headers: []string{
+				"PROXY TCP4 192.0.2.1 192.0.2.9 1000 443\r\n",
+				"PROXY TCP4 192.0.2.2 192.0.2.9 1000 443\r\n",
+				"PROXY TCP6 2001:db8::1 2001:db8::9 1000 443\r\n",
+				"PROXY TCP4 192.0.2.1 192.0.2.9 1001 443\r\n",
+			},
+			want: []string{"192.0.2.1", "192.0.2.2", "2001:db8::1", ""},
+		},
+		{
+			name:    "health checks of the proxy",
+			options: &Options{TrustedProxies: []string{"127.0.0.1"}, ProxyProtocol: true},
+			headers: []string{"PROXY UNKNOWN\r\n", "PROXY UNKNOWN\r\n"},
+			want:    []string{"127.0.0.1", "127.0.0.1"},
+		},
+		{
+			name:    "trusted proxy without PROXY protocol",
+			options: &Options{TrustedProxies: []string{"127.0.0.1"}},
+			headers: []string{"", "", ""},
+			want:    []string{"127.0.0.1", "127.0.0.1", "127.0.0.1"},
+		},
+		{
+			name:    "untrusted client",
+			options: &Options{TrustedProxies: []string{"10.0.0.0/8"}},
+			headers: []string{"", ""},
+			want:    []string{"127.0.0.1", ""},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			lis, err := listen("127.0.0.1:0", tc.options, connlimit.Limits{PerIP: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer lis.Close()
+
+			go func() {
+				for {
+					conn, err := lis.Accept()
+					if err != nil {
+						return
+					}
+
+					go func() {
+						if _, err := bufio.NewReader(conn).ReadString('\n'); err != nil {
+							_ = conn.Close()
+							return
+						}
+
+						host, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+						_, _ = conn.Write([]byte(host + "\n"))
+					}()
+				}
+			}()
+
+			for idx, header := range tc.headers {
+				conn, err := net.Dial("tcp", lis.Addr().String())
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer conn.Close()
+
+				if _, err := conn.Write([]byte(header + "hello\n")); err != nil {
+					t.Fatal(err)
+				}
+
+				line, _ := bufio.NewReader(conn).ReadString('\n')
+				if got := strings.TrimSuffix(line, "\n"); got != tc.want[idx] {
+					t.Errorf("connection %d: got client %q, want %q", idx, got, tc.want[idx])
+				}
+			}
+		})
+	}
+}
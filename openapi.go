@@ -0,0 +1,44 @@
+package wab
+
+import (
+	"google.golang.org/grpc"
+
+	"github.com/fernferret/wab/internal/descriptors"
+	"github.com/fernferret/wab/internal/openapi"
+	"github.com/fernferret/wab/internal/transcode"
+)
+
+// apiTitle is the name the API goes by in grpcui and the OpenAPI document.
+const apiTitle = "Web Application Bootstrap"
+
+// OpenAPI builds the OpenAPI document of the REST/JSON routes without
+// starting any servers. It's the same document served at /api/openapi.json.
+func OpenAPI(version string) (*openapi.Document, error) {
+	baseSvr := grpc.NewServer()
+	greeterVersions(NewGRPCServer()).Register(baseSvr)
+
+	// The handler is only used for its bindings, nothing is ever called.
+	handler, err := transcode.New(nil, registeredServices(baseSvr)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildOpenAPI(version, handler.Bindings())
+}
+
+func buildOpenAPI(version string, bindings []*transcode.Binding) (*openapi.Document, error) {
+	// The bindings point at the compiled-in descriptors, the resolver is only
+	// used to look up comments.
+	resolver, err := descriptors.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	info := openapi.Info{
+		Title:       apiTitle,
+		Description: "REST/JSON routes generated from the google.api.http annotations of the gRPC services.",
+		Version:     version,
+	}
+
+	return openapi.Generate(info, bindings, resolver), nil
+}
@@ -10,3 +10,42 @@ import (
 
 //go:embed greeter.proto
 var Greeter string
+
+// GreeterV2 is version 2 of the greeter API, served next to Greeter.
+//
+//go:embed greeter_v2.proto
+var GreeterV2 string
+
+// DescriptorSet is the FileDescriptorSet protoc builds from the service files
+// and their imports, with source info so the comments survive. `make proto`
+// regenerates it alongside the Go code.
+//
+//go:embed descriptors.binpb
+var DescriptorSet []byte
+
+// Validate holds the (wab.validate.rules) field options imported by
+// greeter.proto.
+//
+//go:embed validate.proto
+var Validate string
+
+// GoogleAPIAnnotations and GoogleAPIHTTP are local copies of the googleapis
+// files that define the (google.api.http) option used for the REST routes.
+//
+//go:embed google/api/annotations.proto
+var GoogleAPIAnnotations string
+
+//go:embed google/api/http.proto
+var GoogleAPIHTTP string
+
+// Sources maps the path of every embedded .proto file to its contents, as
+// protoc sees them with -I proto.
+func Sources() map[string]string {
+	return map[string]string{
+		"greeter.proto":                Greeter,
+		"greeter_v2.proto":             GreeterV2,
+		"validate.proto":               Validate,
+		"google/api/annotations.proto": GoogleAPIAnnotations,
+		"google/api/http.proto":        GoogleAPIHTTP,
+	}
+}
@@ -0,0 +1,81 @@
+package wab
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/fernferret/wab/internal/static"
+)
+
+// getAndHead is for routes that only read, HEAD gets the same headers
+// without the body.
+var getAndHead = []string{http.MethodGet, http.MethodHead}
+
+// reservedPrefixes belong to the backend. Unknown paths under them are typos
+// in API calls and get a 404 instead of the UI.
+var reservedPrefixes = []string{"/api", "/connect", "/grpc", "/grpc-ui"}
+
+// spaFallback serves the paths no other route took. files returns the current
+// assets, it's nil when there's no UI. Files of the UI (like /favicon.ico) are
+// served as is, except /index.html, which only works as served by serveUI
+// with the nonce and runtime config. Reserved prefixes and paths that look
+// like files get a real 404 so browsers don't choke on HTML where they
+// expected a script. Everything else is a history-mode route of the Vue app
+// and gets serveUI, with a 404 status when UIRoutes is set and doesn't list
+// the path.
+func (s *WebServer) spaFallback(files func() *static.Handler, serveUI func(ectx echo.Context, status int) error) echo.HandlerFunc {
+	return func(ectx echo.Context) error {
+		urlPath := ectx.Request().URL.Path
+
+		var assets *static.Handler
+		if files != nil {
+			assets = files()
+		}
+
+		switch {
+		case reserved(urlPath):
+			return echo.ErrNotFound
+		case urlPath == "/index.html":
+			return serveUI(ectx, http.StatusOK)
+		case assets != nil && assets.Has(urlPath):
+			assets.ServeHTTP(ectx.Response(), ectx.Request())
+			return nil
+		case s.uiRoute(urlPath):
+			return serveUI(ectx, http.StatusOK)
+		case path.Ext(urlPath) != "":
+			return echo.ErrNotFound
+		case len(s.options.UIRoutes) > 0:
+			return serveUI(ectx, http.StatusNotFound)
+		}
+
+		return serveUI(ectx, http.StatusOK)
+	}
+}
+
+// uiRoute reports whether urlPath is listed in UIRoutes.
+func (s *WebServer) uiRoute(urlPath string) bool {
+	if urlPath != "/" {
+		urlPath = strings.TrimSuffix(urlPath, "/")
+	}
+
+	for _, pattern := range s.options.UIRoutes {
+		if ok, _ := path.Match(pattern, urlPath); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func reserved(urlPath string) bool {
+	for _, prefix := range reservedPrefixes {
+		if urlPath == prefix || strings.HasPrefix(urlPath, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,169 @@
+package wab
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/fernferret/wab/internal/static"
+	"github.com/fernferret/wab/internal/wabmw"
+)
+
+// testIndex is a Vite index.html, its URLs are absolute.
+const testIndex = `<!DOCTYPE html><html><head><script type="module" src="/assets/index-4f1c9a7b.js"></script></head><body><div id="app"></div></body></html>`
+
+var cspNonce = regexp.MustCompile(`'nonce-([^']+)'`)
+
+func TestSPAFallback(t *testing.T) {
+	assets, err := static.New(fstest.MapFS{
+		"index.html":  {Data: []byte(testIndex)},
+		"favicon.ico": {Data: []byte{0, 0, 1, 0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		uiRoutes []string
+		path     string
+		status   int
+		// ui is true when the Vue app answers, false for a file or a plain 404.
+		ui bool
+	}{
+		{name: "root", path: "/", status: http.StatusOK, ui: true},
+		{name: "history route", path: "/greet/bob", status: http.StatusOK, ui: true},
+		{name: "file", path: "/favicon.ico", status: http.StatusOK},
+		{name: "missing file", path: "/assets/missing.js", status: http.StatusNotFound},
+		{name: "dotted missing file", path: "/app.min.css", status: http.StatusNotFound},
+		{name: "api typo", path: "/api/v1/gret", status: http.StatusNotFound},
+		{name: "reserved prefix itself", path: "/connect", status: http.StatusNotFound},
+		{name: "grpc typo", path: "/grpc-ui/oops", status: http.StatusNotFound},
+		{name: "not a reserved prefix", path: "/apiary", status: http.StatusOK, ui: true},
+		{name: "listed route", uiRoutes: []string{"/", "/greet/*"}, path: "/greet/bob", status: http.StatusOK, ui: true},
+		{name: "listed route with a slash", uiRoutes: []string{"/about"}, path: "/about/", status: http.StatusOK, ui: true},
+		{name: "listed route with a dot", uiRoutes: []string{"/users/*"}, path: "/users/j.doe", status: http.StatusOK, ui: true},
+		{name: "unlisted route", uiRoutes: []string{"/", "/greet/*"}, path: "/nope", status: http.StatusNotFound, ui: true},
+		{name: "unlisted file", uiRoutes: []string{"/"}, path: "/missing.js", status: http.StatusNotFound},
+		{name: "index.html", path: "/index.html", status: http.StatusOK, ui: true},
+		{name: "unlisted index.html", uiRoutes: []string{"/about"}, path: "/index.html", status: http.StatusOK, ui: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewAPIServer(&Options{UIRoutes: tc.uiRoutes})
+
+			served := false
+			handler := s.spaFallback(func() *static.Handler { return assets }, func(ectx echo.Context, status int) error {
+				served = true
+
+				return ectx.HTML(status, "<html>app</html>")
+			})
+
+			e := echo.New()
+			e.Match(getAndHead, "/*", handler)
+
+			resp := httptest.NewRecorder()
+			e.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if resp.Code != tc.status {
+				t.Errorf("got status %d, want %d", resp.Code, tc.status)
+			}
+
+			if served != tc.ui {
+				t.Errorf("got UI served %v, want %v", served, tc.ui)
+			}
+		})
+	}
+}
+
+func TestSPAFallbackWithoutUI(t *testing.T) {
+	s := NewAPIServer(&Options{})
+
+	e := echo.New()
+	e.Match(getAndHead, "/*", s.spaFallback(nil, func(ectx echo.Context, status int) error {
+		return ectx.String(status, "no UI")
+	}))
+
+	for path, want := range map[string]int{"/": http.StatusOK, "/favicon.ico": http.StatusNotFound, "/api/x": http.StatusNotFound} {
+		resp := httptest.NewRecorder()
+		e.ServeHTTP(resp, httptest.NewRequest(http.MethodHead, path, nil))
+
+		if resp.Code != want {
+			t.Errorf("HEAD %s: got status %d, want %d", path, resp.Code, want)
+		}
+	}
+}
+
+// TestIndexHTML requests index.html the ways a browser can reach it, under a
+// base path. Each answer must be the served UI, with this response's nonce on
+// its scripts, the runtime config and the base path, and must not be stored.
+func TestIndexHTML(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte(testIndex), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	options := &Options{
+		DisableGRPCUI: true,
+		UIDir:         dir,
+		BasePath:      "/tools/wab",
+		CSP:           wabmw.DefaultCSP,
+	}
+
+	s := NewAPIServer(options)
+	s.setupHTTPServer(SetupGRPCHTTPHandler(options))
+
+	nonces := map[string]bool{}
+
+	for _, target := range []string{"/tools/wab/", "/tools/wab/index.html", "/tools/wab/greet/bob"} {
+		t.Run(target, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			req.Header.Set("If-None-Match", "*")
+
+			resp := httptest.NewRecorder()
+			s.e.ServeHTTP(resp, req)
+
+			if resp.Code != http.StatusOK {
+				t.Fatalf("got status %d, want %d", resp.Code, http.StatusOK)
+			}
+
+			match := cspNonce.FindStringSubmatch(resp.Header().Get("Content-Security-Policy"))
+			if match == nil {
+				t.Fatalf("got no nonce in the CSP %q", resp.Header().Get("Content-Security-Policy"))
+			}
+
+			nonces[match[1]] = true
+			body := resp.Body.String()
+
+			for _, want := range []string{
+				`<script nonce="` + match[1] + `" type="module" src="/tools/wab/assets/index-4f1c9a7b.js">`,
+				`<base href="/tools/wab/">`,
+				uiConfigGlobal + " = ",
+			} {
+				if !strings.Contains(body, want) {
+					t.Errorf("got %s, want it to contain %s", body, want)
+				}
+			}
+
+			if got := resp.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("got Cache-Control %q, want no-store", got)
+			}
+
+			if got := resp.Header().Get("ETag"); got != "" {
+				t.Errorf("got ETag %s, want none", got)
+			}
+		})
+	}
+
+	if len(nonces) != 3 {
+		t.Errorf("got %d different nonces for 3 responses", len(nonces))
+	}
+}
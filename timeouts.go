@@ -0,0 +1,35 @@
+package wab
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+
+	"github.com/fernferret/wab/internal/transcode"
+)
+
+// streamingRequest reports whether a request can outlive the read and write
+// timeouts: websockets (grpcweb's and the Vite HMR one of --ui-proxy),
+// grpcweb, whose server-streaming calls share the unary paths, grpcui, which
+// answers a stream as a whole once it ends, Server-Sent Events, streaming
+// Connect calls and the NDJSON streams of rest, which may be nil.
+func streamingRequest(rest *transcode.Handler) middleware.Skipper {
+	return func(ectx echo.Context) bool {
+		req := ectx.Request()
+		urlPath := req.URL.Path
+
+		switch {
+		case strings.EqualFold(req.Header.Get(echo.HeaderUpgrade), "websocket"):
+			return true
+		case strings.HasPrefix(urlPath, "/grpc/"), strings.HasPrefix(urlPath, "/grpc-ui/"), strings.HasPrefix(urlPath, "/api/sse/"):
+			return true
+		case strings.HasPrefix(urlPath, "/connect/"):
+			// Unary Connect calls are plain application/json or
+			// application/proto, streams are application/connect+json and +proto.
+			return strings.HasPrefix(req.Header.Get(echo.HeaderContentType), "application/connect+")
+		}
+
+		return rest != nil && rest.Streaming(ectx)
+	}
+}
@@ -0,0 +1,123 @@
+package wab
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestStreamingRequest(t *testing.T) {
+	handlers := SetupGRPCHTTPHandler(&Options{DisableGRPCUI: true})
+	skipper := streamingRequest(handlers.REST)
+
+	cases := []struct {
+		name        string
+		method      string
+		path        string
+		header      http.Header
+		body        string
+		streaming   bool
+		withoutREST bool
+	}{
+		{name: "unary REST", method: http.MethodPost, path: "/api/v1/greet", body: `{"name":"bob"}`},
+		{name: "v1 NDJSON stream", method: http.MethodPost, path: "/api/v1/greet-many", body: `{"request":{"name":"bob"}}`, streaming: true},
+		{name: "v1 NDJSON stream by GET", method: http.MethodGet, path: "/api/v1/greet-many/bob", streaming: true},
+		{name: "v2 NDJSON stream", method: http.MethodPost, path: "/api/v2/greet-many", body: `{"name":"bob"}`, streaming: true},
+		{name: "v2 NDJSON stream by GET", method: http.MethodGet, path: "/api/v2/greet-many/bob", streaming: true},
+		{name: "NDJSON stream without REST", method: http.MethodPost, path: "/api/v1/greet-many", withoutREST: true},
+		{name: "grpcweb", method: http.MethodPost, path: "/grpc/wab.greeter.v1.Greeter/Greet", streaming: true},
+		{name: "grpcui", method: http.MethodPost, path: "/grpc-ui/invoke/wab.greeter.v1.Greeter.GreetMany", streaming: true},
+		{name: "Server-Sent Events", method: http.MethodGet, path: "/api/sse/wab.greeter.v1.Greeter/GreetMany", streaming: true},
+		{name: "unary Connect", method: http.MethodPost, path: "/connect/wab.greeter.v1.Greeter/Greet", header: http.Header{"Content-Type": {"application/json"}}},
+		{name: "streaming Connect", method: http.MethodPost, path: "/connect/wab.greeter.v1.Greeter/GreetMany", header: http.Header{"Content-Type": {"application/connect+json"}}, streaming: true},
+		{name: "websocket", method: http.MethodGet, path: "/", header: http.Header{"Upgrade": {"WebSocket"}}, streaming: true},
+		{name: "UI", method: http.MethodGet, path: "/"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			skip := skipper
+			if tc.withoutREST {
+				skip = streamingRequest(nil)
+			}
+
+			var got bool
+
+			e := echo.New()
+			e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(ectx echo.Context) error {
+					got = skip(ectx)
+
+					return nil
+				}
+			})
+			e.Any("/*", func(ectx echo.Context) error { return nil })
+			handlers.REST.Register(e)
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			for key, values := range tc.header {
+				req.Header[key] = values
+			}
+
+			e.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tc.streaming {
+				t.Errorf("got streaming %v, want %v", got, tc.streaming)
+			}
+		})
+	}
+}
+
+// TestGRPCUIStreamOutlivesTimeouts runs GreetMany through grpcui, which only
+// answers once the stream ends, for longer than the read and write timeouts.
+func TestGRPCUIStreamOutlivesTimeouts(t *testing.T) {
+	options := &Options{
+		ReadTimeout:  100 * time.Millisecond,
+		WriteTimeout: 200 * time.Millisecond,
+	}
+
+	s := NewAPIServer(options)
+	s.setupHTTPServer(SetupGRPCHTTPHandler(options))
+
+	server := httptest.NewServer(s.e)
+	defer server.Close()
+
+	body := `{"metadata": [], "data": [{"request": {"name": "bob"}, "qty": 2, "sleep_seconds": 1}]}`
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/grpc-ui/invoke/wab.greeter.v1.Greeter.GreetMany", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Grpcui-Csrf-Token", "token")
+	req.AddCookie(&http.Cookie{Name: "_grpcui_csrf_token", Value: "token"})
+
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("the call failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Error     *struct{ Message string } `json:"error"`
+		Responses []json.RawMessage         `json:"responses"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("got status %d and an unreadable result: %v", resp.StatusCode, err)
+	}
+
+	if result.Error != nil {
+		t.Fatalf("the stream failed: %s", result.Error.Message)
+	}
+
+	if len(result.Responses) != 2 {
+		t.Errorf("got %d responses, want 2", len(result.Responses))
+	}
+}
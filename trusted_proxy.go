@@ -0,0 +1,22 @@
+package wab
+
+import (
+	"time"
+
+	"github.com/fernferret/wab/internal/trustedproxy"
+)
+
+// proxyHeaderTimeout is how long a trusted proxy gets to send the PROXY
+// protocol header of a new connection.
+const proxyHeaderTimeout = 5 * time.Second
+
+// trustedProxies is the parsed --trusted-proxy list, RunLoop has already
+// checked it.
+func trustedProxies(options *Options) *trustedproxy.Trusted {
+	trusted, err := trustedproxy.Parse(options.TrustedProxies)
+	if err != nil {
+		return &trustedproxy.Trusted{}
+	}
+
+	return trusted
+}
@@ -1,14 +1,17 @@
 import { ref, onBeforeMount } from 'vue'
 
+import { useConfig } from '@/config'
 import { GreeterClientImpl, GrpcWebImpl } from '@/gen/greeter'
 
 export const useApi = () => {
   const client = ref(null as null | GreeterClientImpl)
 
   onBeforeMount(() => {
+    const { apiBase } = useConfig()
+
     let url = 'grpc'
-    if (import.meta.env.VITE_API_ADDR !== undefined) {
-      url = `${import.meta.env.VITE_API_ADDR}/grpc`
+    if (apiBase !== '') {
+      url = `${apiBase}/grpc`
     }
     const rpc = new GrpcWebImpl(url, {
       debug: import.meta.env.DEV,
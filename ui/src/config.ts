@@ -0,0 +1,30 @@
+// WabConfig is handed to the app by the server at runtime, injected into
+// index.html (see ui_config.go). The Vite dev server's index.html doesn't
+// have it, the build-time VITE_API_ADDR is used there instead.
+export interface WabConfig {
+  apiBase: string
+  basePath: string
+  version: string
+  authMode: string
+  features: Record<string, boolean>
+}
+
+declare global {
+  interface Window {
+    __WAB_CONFIG__?: WabConfig
+  }
+}
+
+export const useConfig = (): WabConfig => {
+  if (window.__WAB_CONFIG__ !== undefined) {
+    return window.__WAB_CONFIG__
+  }
+
+  return {
+    apiBase: import.meta.env.VITE_API_ADDR ?? '',
+    basePath: import.meta.env.BASE_URL,
+    version: 'dev',
+    authMode: 'none',
+    features: {},
+  }
+}
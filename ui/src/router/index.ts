@@ -1,8 +1,10 @@
 import { createRouter, createWebHistory } from 'vue-router'
+import { useConfig } from '@/config'
 import GreeterView from '../views/GreeterView.vue'
 
 const router = createRouter({
-  history: createWebHistory(import.meta.env.BASE_URL),
+  // wab tells the app where it's mounted, see --base-path.
+  history: createWebHistory(useConfig().basePath),
   routes: [
     {
       path: '/',
@@ -5,6 +5,9 @@ import vue from '@vitejs/plugin-vue'
 
 // https://vitejs.dev/config/
 export default defineConfig({
+  // Relative asset URLs, wab serves the build under any --base-path and points
+  // index.html at it with a <base href>.
+  base: './',
   plugins: [vue()],
   resolve: {
     alias: {
@@ -0,0 +1,97 @@
+package wab
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/fernferret/wab/internal/static"
+)
+
+// uiConfigGlobal is the global the Vue app reads its runtime config from, see
+// ui/src/config.ts.
+const uiConfigGlobal = "window.__WAB_CONFIG__"
+
+// UIConfig is what the Vue app learns from the server at runtime instead of
+// at build time, so the same bundle runs behind any address.
+type UIConfig struct {
+	// APIBase is prefixed to the API paths (like /grpc), empty means the
+	// origin index.html came from.
+	APIBase string `json:"apiBase"`
+	// BasePath is where wab is mounted, like /tools/wab/, the Vue router's
+	// base.
+	BasePath string `json:"basePath"`
+
+	Version  string          `json:"version"`
+	AuthMode string          `json:"authMode"`
+	Features map[string]bool `json:"features"`
+}
+
+// newUIConfig describes the server built from options, features are on when
+// their handler exists.
+func newUIConfig(options *Options, handlers *GRPCHandlers) UIConfig {
+	return UIConfig{
+		APIBase:  options.UIAPIBase,
+		BasePath: normalizeBasePath(options.BasePath) + "/",
+		Version:  options.Version,
+		AuthMode: options.UIAuthMode,
+		Features: map[string]bool{
+			"grpcweb":           handlers.GRPCWeb != nil,
+			"grpcwebWebsockets": handlers.GRPCWeb != nil && !options.DisableGRPCWebSockets,
+			"grpcui":            handlers.GRPCUI != nil,
+			"rest":              handlers.REST != nil,
+			"sse":               handlers.SSE != nil,
+			"connect":           handlers.Connect != nil,
+			"apiDocs":           handlers.APIDocs != nil,
+		},
+	}
+}
+
+// script is the config as JavaScript assigning uiConfigGlobal. json.Marshal
+// escapes <, > and &, so it's safe inside a <script> tag.
+func (c UIConfig) script() []byte {
+	data, _ := json.Marshal(c)
+
+	return []byte(uiConfigGlobal + " = " + string(data) + ";\n")
+}
+
+// inject adds the config to html as an inline script at the end of <head>,
+// ahead of the module scripts that read it, and moves the page under
+// BasePath. InjectNonce gives the script the nonce like any other.
+func (c UIConfig) inject(html []byte) []byte {
+	html = rebaseHTML(html, c.BasePath)
+
+	tag := append(append([]byte("<script>"), bytes.TrimSpace(c.script())...), "</script>"...)
+
+	idx := bytes.Index(html, []byte("</head>"))
+	if idx < 0 {
+		return append(tag, html...)
+	}
+
+	out := make([]byte, 0, len(html)+len(tag))
+	out = append(out, html[:idx]...)
+	out = append(out, tag...)
+
+	return append(out, html[idx:]...)
+}
+
+// uiConfigHandler serves the config as a script for pages that don't get the
+// injected one, like the Vite dev server's index.html.
+func uiConfigHandler(config UIConfig) echo.HandlerFunc {
+	script := config.script()
+	etag := static.ETag(script)
+
+	return func(ectx echo.Context) error {
+		header := ectx.Response().Header()
+		header.Set("Cache-Control", static.CacheRevalidate)
+		header.Set("ETag", etag)
+
+		if static.IfNoneMatch(ectx.Request(), etag) {
+			return ectx.NoContent(http.StatusNotModified)
+		}
+
+		return ectx.Blob(http.StatusOK, "text/javascript; charset=utf-8", script)
+	}
+}
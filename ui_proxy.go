@@ -0,0 +1,79 @@
+package wab
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/fernferret/wab/internal/wabmw"
+)
+
+// uiProxyHandler forwards everything outside the reserved prefixes to the
+// Vite dev server at target, its HMR websocket included, so the UI and the
+// API share one origin during development. HTML pages get the runtime config
+// and the CSP nonce like the embedded index.html.
+func (s *WebServer) uiProxyHandler(target *url.URL, uiConfig UIConfig) echo.HandlerFunc {
+	return func(ectx echo.Context) error {
+		if reserved(ectx.Request().URL.Path) {
+			return echo.ErrNotFound
+		}
+
+		nonce := wabmw.CSPNonce(ectx)
+
+		proxy := httputil.NewSingleHostReverseProxy(target)
+
+		director := proxy.Director
+		proxy.Director = func(req *http.Request) {
+			director(req)
+			req.Host = target.Host
+
+			// Without an Accept-Encoding of its own, the transport asks for gzip
+			// and hands us the decompressed body, ready for injecting.
+			req.Header.Del("Accept-Encoding")
+		}
+
+		proxy.ModifyResponse = func(resp *http.Response) error {
+			if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+				return nil
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+
+			if err != nil {
+				return err
+			}
+
+			body = wabmw.InjectNonce(uiConfig.inject(body), nonce)
+
+			// Every response has its own nonce, a stored copy would replay it.
+			resp.Header.Del("ETag")
+			resp.Header.Del("Last-Modified")
+			resp.Header.Set("Cache-Control", "no-store")
+			resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+			resp.ContentLength = int64(len(body))
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+
+			return nil
+		}
+
+		proxy.ErrorHandler = func(resp http.ResponseWriter, req *http.Request, err error) {
+			s.log.Warnw("Failed to reach the UI dev server", "target", target.String(), "path", req.URL.Path, "err", err)
+
+			resp.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			resp.WriteHeader(http.StatusBadGateway)
+			fmt.Fprintf(resp, "The UI dev server at %s isn't answering, is make dev-ui running?\n", target)
+		}
+
+		proxy.ServeHTTP(ectx.Response(), ectx.Request())
+
+		return nil
+	}
+}
@@ -3,26 +3,39 @@ package wab
 //go:generate go run -tags=dev webui_generate.go
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io/fs"
 	"net/http"
+	"net/url"
 	"os"
-
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	glog "github.com/labstack/gommon/log"
 	"go.uber.org/zap"
-
+	"google.golang.org/grpc/status"
+
+	"github.com/fernferret/wab/internal/apierr"
+	"github.com/fernferret/wab/internal/connlimit"
+	"github.com/fernferret/wab/internal/static"
+	"github.com/fernferret/wab/internal/trustedproxy"
+	"github.com/fernferret/wab/internal/uisource"
+	"github.com/fernferret/wab/internal/versions"
 	"github.com/fernferret/wab/internal/wabmw"
 	"github.com/fernferret/wab/ui"
 )
 
 // Options contains all the information about the web api. These are options like host, port, dev mode, etc.
 type Options struct {
+	Version           string
 	Bind              string
-	DevMode           bool // If true, CORS headers will be not good.
+	BasePath          string // Mounts every route under this path, like /tools/wab.
+	DevMode           bool   // If true, the Vite dev server origins are allowed.
 	LogRequests       bool
 	BuildMode         string
 	DisableGRPCUI     bool
@@ -30,6 +43,79 @@ type Options struct {
 	BindGRPC          string
 	DisableGRPC       bool
 	DisableReflection bool
+	DisableREST       bool
+	DisableSSE        bool
+	DisableConnect    bool
+	DisableAPIDocs    bool
+
+	// UIRoutes are the routes of the Vue app, path.Match patterns like /about
+	// or /users/*. When set, other paths get index.html with a 404 status.
+	UIRoutes []string
+
+	// UIProxy, UIDir and UIBundle serve the UI from a dev server, a directory
+	// or a zip file instead of the embedded files, only one can be set. A UIDir
+	// is checked for changes every UIWatchInterval, 0 disables that.
+	UIProxy         string
+	UIDir           string
+	UIBundle        string
+	UIWatchInterval time.Duration
+
+	// UIAPIBase and UIAuthMode are handed to the Vue app at runtime, see
+	// UIConfig.
+	UIAPIBase  string
+	UIAuthMode string
+
+	// Service name and file patterns (path.Match syntax) deciding what gRPC
+	// reflection and grpcui reveal, everything when both are empty. Deny wins
+	// over allow.
+	ReflectionAllow []string
+	ReflectionDeny  []string
+	GRPCUIAllow     []string
+	GRPCUIDeny      []string
+
+	// ProtoDriftCheck is what happens when the embedded .proto files don't
+	// match the generated code, one of the ProtoDrift* values.
+	ProtoDriftCheck string
+
+	// Cross-origin policy for the echo routes, grpcweb and its websockets, see
+	// cors.Policy.
+	CORSOrigins          []string
+	CORSAllowHeaders     []string
+	CORSExposeHeaders    []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+
+	// Security headers, see wabmw.SecurityConfig. TLSCert and TLSKey serve
+	// HTTPS instead of HTTP.
+	CSP            string
+	FrameAncestors string
+	ReferrerPolicy string
+	HSTSMaxAge     time.Duration
+	TLSCert        string
+	TLSKey         string
+
+	// TrustedProxies are the addresses (CIDRs or IPs) whose Forwarded and
+	// X-Forwarded-* headers are believed. With ProxyProtocol they also have to
+	// send a PROXY protocol header on both listeners.
+	TrustedProxies []string
+	ProxyProtocol  bool
+
+	// grpcweb websocket transport, needed for client-streaming and bidi calls
+	// from the browser.
+	DisableGRPCWebSockets   bool
+	WebsocketPingInterval   time.Duration
+	WebsocketMaxMessageSize int64
+
+	// HTTP server limits, 0 disables each. ReadTimeout and WriteTimeout apply
+	// per request and skip streams, MaxConnsPerIP counts socket addresses.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	MaxBodySize       int64
+	MaxConns          int
+	MaxConnsPerIP     int
 }
 
 // WebServer holds the internal fields for the HTTP Server (Echo) as well as the HTTP Client
@@ -39,6 +125,9 @@ type WebServer struct {
 	options *Options
 	client  *http.Client
 	log     *zap.SugaredLogger
+
+	// uiSource is where the UI is served from.
+	uiSource *uisource.Source
 	// object  *api.Object
 }
 
@@ -65,39 +154,52 @@ func (s *WebServer) RunLoop() {
 	// Start a GRPCServer and setup the webui for debugging.
 	//
 
-	var grpcWebHdlr, debugUIHdlr http.Handler
+	checkProtoDrift(s.log, s.options.ProtoDriftCheck)
+
+	if err := corsPolicy(s.options).Validate(); err != nil {
+		s.log.Fatalf("Invalid CORS policy: %v", err)
+	}
+
+	if trusted, err := trustedproxy.Parse(s.options.TrustedProxies); err != nil {
+		s.log.Fatalf("Invalid --trusted-proxy: %v", err)
+	} else if s.options.ProxyProtocol && trusted.Empty() {
+		s.log.Fatalf("--proxy-protocol needs the proxies sending it in --trusted-proxy")
+	}
+
+	var handlers *GRPCHandlers
 	if s.options.DisableGRPC {
-		grpcWebHdlr, debugUIHdlr = SetupGRPCHTTPHandler(
-			!s.options.DisableGRPCWeb,
-			!s.options.DisableGRPCUI,
-		)
+		handlers = SetupGRPCHTTPHandler(s.options)
 	} else {
-		grpcWebHdlr, debugUIHdlr = ServeGRPC(
-			s.log.With("part", "grpc"),
-			s.options.BindGRPC,
-			!s.options.DisableReflection,
-			!s.options.DisableGRPCWeb,
-			!s.options.DisableGRPCUI,
-		)
+		handlers = ServeGRPC(s.log.With("part", "grpc"), s.options)
 	}
 
-	s.setupHTTPServer(grpcWebHdlr, debugUIHdlr)
+	s.setupHTTPServer(handlers)
+	s.serveHTTP()
 }
 
-// setupHTTPServer creates a new Echo HTTP server. If an http.Handler is passed
-// in, it is assumed to be a grpcui based handler for debugging GRPC user
-// interfaces with GRPCURL.
+// setupHTTPServer creates a new Echo HTTP server. If the handlers contain a
+// GRPCUI handler, it is assumed to be a grpcui based handler for debugging
+// GRPC user interfaces with GRPCURL.
 //
 // The GRPCUI is embedded into the process and is totally standalone.
-func (s *WebServer) setupHTTPServer(grpcWebHdlr, debugHandler http.Handler) {
+func (s *WebServer) setupHTTPServer(handlers *GRPCHandlers) {
 	s.e = echo.New()
 
-	if s.options.DevMode {
-		zap.S().Warn("DevMode enabled; your server is not secure against CORS based attacks.")
-		s.e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-			AllowOrigins: []string{"*"},
-			AllowMethods: []string{http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete},
-		}))
+	// Forwarding headers only count from trusted proxies, the middleware drops
+	// them after working out the client, so RealIP can take the address as is.
+	s.e.IPExtractor = echo.ExtractIPDirect()
+	s.e.Pre(trustedProxies(s.options).Middleware())
+
+	// Behind a reverse proxy's sub-path every route moves under the base path,
+	// the routes below are still registered at the root.
+	if basePath := normalizeBasePath(s.options.BasePath); basePath != "" {
+		s.log.Infof("Serving everything under %s/", basePath)
+		s.e.Pre(wabmw.BasePath(basePath))
+	}
+
+	if policy := corsPolicy(s.options); policy.Enabled() {
+		s.log.Infof("Allowing cross-origin requests from %s", strings.Join(policy.Origins, ", "))
+		s.e.Use(policy.Middleware())
 	}
 
 	// Setup the quiet logger. The default Echo Logger spews junk with it's own formatting.
@@ -115,13 +217,24 @@ func (s *WebServer) setupHTTPServer(grpcWebHdlr, debugHandler http.Handler) {
 
 	s.e.Logger.SetLevel(glog.DEBUG)
 
+	// Requests get a deadline unless they're streams, and a body size limit.
+	s.e.Use(wabmw.RequestTimeouts(wabmw.TimeoutConfig{
+		Read:    s.options.ReadTimeout,
+		Write:   s.options.WriteTimeout,
+		Skipper: streamingRequest(handlers.REST),
+	}))
+
+	if s.options.MaxBodySize > 0 {
+		s.e.Use(middleware.BodyLimit(strconv.FormatInt(s.options.MaxBodySize, 10)))
+	}
+
 	const grpcPath = "/grpc"
 
 	var grpcwebHandler echo.HandlerFunc
 
-	if grpcWebHdlr != nil {
+	if handlers.GRPCWeb != nil {
 		s.log.Infof("Setup grpcweb at %s", grpcPath)
-		grpcwebHandler = echo.WrapHandler(http.StripPrefix(grpcPath, grpcWebHdlr))
+		grpcwebHandler = echo.WrapHandler(http.StripPrefix(grpcPath, handlers.GRPCWeb))
 	} else {
 		grpcwebHandler = noGRPCWebHandler
 	}
@@ -139,99 +252,300 @@ func (s *WebServer) setupHTTPServer(grpcWebHdlr, debugHandler http.Handler) {
 
 	var grpcUIDebugHandler echo.HandlerFunc
 
-	if debugHandler != nil {
+	if handlers.GRPCUI != nil {
 		s.log.Infof("Setup GRPC UI at %s", grpcUIPath)
-		grpcUIDebugHandler = echo.WrapHandler(http.StripPrefix(grpcUIPath, debugHandler))
+		grpcUIDebugHandler = echo.WrapHandler(http.StripPrefix(grpcUIPath, handlers.GRPCUI))
 	} else {
 		grpcUIDebugHandler = noGRPCUIHandler
 	}
 
 	s.e.Any(fmt.Sprintf("%s/*", grpcUIPath), grpcUIDebugHandler)
 
-	// Setup the handler that will serve the embedded VueJS application.
-	s.setupStaticHandler()
+	// grpcui is built on inline scripts, so it only gets the headers that
+	// don't break it.
+	s.e.Use(wabmw.SecurityHeaders(wabmw.SecurityConfig{
+		CSP:            s.csp(),
+		FrameAncestors: s.options.FrameAncestors,
+		ReferrerPolicy: s.options.ReferrerPolicy,
+		HSTSMaxAge:     s.options.HSTSMaxAge,
+		SkipCSP: func(ectx echo.Context) bool {
+			return strings.HasPrefix(ectx.Request().URL.Path, grpcUIPath+"/")
+		},
+	}))
+
+	// The REST/JSON routes come straight from the google.api.http annotations
+	// in the proto files, so they're registered individually.
+	if handlers.REST != nil {
+		s.log.Infof("Setup REST/JSON API with %d routes", len(handlers.REST.Bindings()))
+		handlers.REST.Register(s.e)
+	}
+
+	if handlers.OpenAPI != nil {
+		s.e.Match(getAndHead, "/api/openapi.json", func(ectx echo.Context) error {
+			return ectx.JSON(http.StatusOK, handlers.OpenAPI)
+		})
+	}
+
+	// The exact schema of the running server, for generating clients without
+	// server reflection.
+	if handlers.Descriptors != nil {
+		s.log.Infof("Serving descriptor set %s at /api/descriptors", handlers.Descriptors.ETag())
+		s.e.Match(getAndHead, "/api/descriptors", echo.WrapHandler(handlers.Descriptors))
+	}
+
+	// A reference of every service, message and enum rendered from the proto
+	// comments. It's served by the backend so it works with the noui tag too.
+	const apiDocsPath = "/docs/api"
+
+	if handlers.APIDocs != nil {
+		s.log.Infof("Setup API reference at %s", apiDocsPath)
+		s.e.Match(getAndHead, apiDocsPath, echo.WrapHandler(handlers.APIDocs))
+	}
+
+	// Usage counters of every API version, to tell when an old version has no
+	// callers left.
+	s.e.Match(getAndHead, "/api/v1/versions", func(ectx echo.Context) error {
+		usage := []versions.Usage{}
+		for _, set := range handlers.Versions {
+			usage = append(usage, set.Usage()...)
+		}
+
+		return ectx.JSON(http.StatusOK, usage)
+	})
+
+	// Every server-streaming method can also be consumed as Server-Sent Events,
+	// which is handy for dashboards and curl.
+	const ssePath = "/api/sse"
+
+	if handlers.SSE != nil {
+		s.log.Infof("Setup Server-Sent Events for %d methods at %s", len(handlers.SSE.Methods()), ssePath)
+		handlers.SSE.Register(s.e, ssePath)
+	}
+
+	// Connect clients POST to /connect/<service>/<method>, with JSON or binary
+	// bodies.
+	const connectPath = "/connect"
+
+	if handlers.Connect != nil {
+		s.log.Infof("Setup Connect protocol for %d methods at %s", len(handlers.Connect.Methods()), connectPath)
+		handlers.Connect.Register(s.e, connectPath)
+	}
+
+	// The Vue app gets its API address and the enabled features at runtime,
+	// injected into index.html and at /api/v1/config.js.
+	uiConfig := newUIConfig(s.options, handlers)
+	s.e.Match(getAndHead, "/api/v1/config.js", uiConfigHandler(uiConfig))
+
+	// Setup the handler that will serve the VueJS application, from the dev
+	// server of --ui-proxy, --ui-dir, --ui-bundle or the files embedded at
+	// build time.
+	source, err := uisource.Open(s.options.UIProxy, s.options.UIDir, s.options.UIBundle, ui.GetAssets())
+	if err != nil {
+		s.log.Fatalw("Failed to open the UI", "err", err)
+	}
+
+	s.uiSource = source
+	s.log.Infof("Serving the UI from %s", s.uiSource)
+	s.setupStaticHandler(uiConfig)
 
 	s.setupRoutes()
 	s.e.HideBanner = true
 	s.e.HidePort = true
+}
+
+// serveHTTP serves the echo server built by setupHTTPServer until it fails.
+func (s *WebServer) serveHTTP() {
+	// The listener is opened here instead of by echo, it caps the connections
+	// and reads the PROXY protocol header of trusted proxies.
+	lis, err := listen(s.options.Bind, s.options, connlimit.Limits{
+		Total: s.options.MaxConns,
+		PerIP: s.options.MaxConnsPerIP,
+	})
+	if err != nil {
+		s.log.Fatalw("Failed to listen", "bind", s.options.Bind, "err", err)
+	}
+
+	server := s.e.Server
+
+	// Either TLS flag turns TLS on, a missing half fails to load.
+	if s.options.TLSCert != "" || s.options.TLSKey != "" {
+		config, err := tlsConfig(s.options)
+		if err != nil {
+			s.log.Fatalw("Failed to load the TLS certificate", "err", err)
+		}
+
+		lis = tls.NewListener(lis, config)
+		s.e.TLSListener = lis
+		server = s.e.TLSServer
+		server.TLSConfig = config
+	} else {
+		s.e.Listener = lis
+	}
+
+	// The connection-wide read and write timeouts would cut streams off, those
+	// are set per request by RequestTimeouts.
+	server.Handler = s.e
+	server.ReadHeaderTimeout = s.options.ReadHeaderTimeout
+	server.IdleTimeout = s.options.IdleTimeout
+	server.MaxHeaderBytes = s.options.MaxHeaderBytes
 
-	if err := s.e.Start(s.options.Bind); err != nil {
+	err = server.Serve(lis)
+	if err != nil {
 		s.e.Logger.Info(fmt.Sprintf("shutting down the server: %s", err))
 		os.Exit(1)
 	}
 }
 
-// quietHTTPErrorHandler is identical to the built-in error handler, but I tailored it
+// quietHTTPErrorHandler is similar to the built-in error handler, but I
+// tailored it to render every error as an RFC 7807 problem document. gRPC
+// status errors keep their code and error details, so the UI sees the same
+// shape it would get from a grpcweb call.
 func (s *WebServer) quietHTTPErrorHandler(err error, ectx echo.Context) {
-	var (
-		code = http.StatusInternalServerError
-		msg  interface{}
-	)
+	var problem *apierr.Problem
 
 	if he, ok := err.(*echo.HTTPError); ok {
-		code = he.Code
-		msg = he.Message
+		problem = apierr.HTTPProblem(he.Code, fmt.Sprint(he.Message))
+	} else if st, ok := status.FromError(err); ok {
+		problem = apierr.StatusProblem(st)
 	} else if s.e.Debug {
-		msg = err.Error()
+		problem = apierr.HTTPProblem(http.StatusInternalServerError, err.Error())
 	} else {
-		msg = http.StatusText(code)
+		problem = apierr.HTTPProblem(http.StatusInternalServerError, "")
 	}
 
-	if _, ok := msg.(string); ok {
-		msg = echo.Map{"message": msg}
+	problem.Instance = ectx.Request().URL.Path
+
+	if ectx.Response().Committed {
+		return
 	}
 
-	if !ectx.Response().Committed {
-		if ectx.Request().Method == echo.HEAD { // Issue #608
-			if err := ectx.NoContent(code); err != nil {
-				goto ERROR
-			}
-		} else {
-			if err := ectx.JSON(code, msg); err != nil {
-				goto ERROR
-			}
-		}
+	if ectx.Request().Method == echo.HEAD { // Issue #608
+		_ = ectx.NoContent(problem.Status)
+
+		return
 	}
-ERROR:
-	// I perform all my logging in the request/response area. I don't want this extra print
-	// e.Logger.Error(err)
-	// log.WithFields(log.Fields{"code": code, "err": err}).Errorf("Unable to respond with error code")
 
-	return
+	// I perform all my logging in the request/response area. I don't want this
+	// extra print if the problem can't be written.
+	_ = apierr.Write(ectx.Response(), problem)
 }
 
-func (s *WebServer) setupStaticHandler() {
-	assets := ui.GetAssets()
-	if assets == nil {
-		s.e.GET("/*", noEmbeddedUIHandler)
-	} else {
-		// Create a handler for the static files
-		// This is done so we can all *ALL* other routes
-		// to go to index.html. This lets Vue.js do history-based
-		// routing that looks great and works great.
-		staticHandler := http.FileServer(http.FS(assets))
-		s.e.GET("/static/*", echo.WrapHandler(staticHandler))
-		s.e.GET("/assets/*", echo.WrapHandler(staticHandler))
-
-		// Load the index.html file. This is the entrypoint for the
-		// entire user-interface and sub-routing actually happens
-		// in here! For example if a user sees:
-		// http://localhost:1323/about in the browser, this is still
-		// serving up index.html but then vue.js is loading the about
-		// sub-page.
-		indexFile, err := fs.ReadFile(assets, "index.html")
-		if err != nil {
-			s.log.Fatalw("Failed to load critical index.html file, cannot continue", "err", err)
-		}
-		s.e.GET("/*", func(ectx echo.Context) error {
-			err := ectx.Blob(http.StatusOK, "text/html", indexFile)
+func (s *WebServer) setupStaticHandler(uiConfig UIConfig) {
+	// Typos under the API prefixes get a 404 whatever the method, instead of
+	// a 405 from the UI fallback below.
+	s.e.Any("/api/*", notFoundHandler)
+	s.e.Any("/connect/*", notFoundHandler)
+
+	if s.uiSource.Kind == uisource.KindProxy {
+		// Open already checked the URL.
+		target, _ := url.Parse(s.uiSource.Path)
+		s.e.Any("/*", s.uiProxyHandler(target, uiConfig))
+
+		return
+	}
+
+	if s.uiSource.FS == nil {
+		s.e.Match(getAndHead, "/*", s.spaFallback(nil, func(ectx echo.Context, status int) error {
+			return ectx.String(status, "No UI embedded in this copy of wab")
+		}))
+
+		return
+	}
+
+	// Load the index.html file and the assets. index.html is the entrypoint
+	// for the entire user-interface and sub-routing actually happens in here!
+	// For example if a user sees: http://localhost:1323/about in the browser,
+	// this is still serving up index.html but then vue.js is loading the about
+	// sub-page.
+	files, err := s.loadUI(uiConfig)
+	if err != nil {
+		s.log.Fatalw("Failed to load the UI, cannot continue", "source", s.uiSource.String(), "err", err)
+	}
+
+	var current atomic.Pointer[uiFiles]
+	current.Store(files)
+
+	// A UI directory is reloaded when it changes, so a running vite build
+	// --watch shows up on the next page load.
+	if s.uiSource.Kind == uisource.KindDir && s.options.UIWatchInterval > 0 {
+		go s.uiSource.Watch(s.options.UIWatchInterval, func() {
+			files, err := s.loadUI(uiConfig)
 			if err != nil {
-				return fmt.Errorf("failed to load blob %q %w", ectx.Request().RequestURI, err)
+				s.log.Warnw("Failed to reload the UI, still serving the previous files", "source", s.uiSource.String(), "err", err)
+				return
 			}
 
-			return nil
+			current.Store(files)
+			s.log.Infof("Reloaded the UI from %s", s.uiSource)
 		})
 	}
+
+	serveStatic := func(ectx echo.Context) error {
+		current.Load().static.ServeHTTP(ectx.Response(), ectx.Request())
+		return nil
+	}
+
+	s.e.Match(getAndHead, "/static/*", serveStatic)
+	s.e.Match(getAndHead, "/assets/*", serveStatic)
+
+	// Create a handler for the static files
+	// This is done so we can all *ALL* other routes
+	// to go to index.html. This lets Vue.js do history-based
+	// routing that looks great and works great.
+	staticFiles := func() *static.Handler { return current.Load().static }
+
+	s.e.Match(getAndHead, "/*", s.spaFallback(staticFiles, func(ectx echo.Context, status int) error {
+		files := current.Load()
+
+		// Every response has its own nonce, a stored copy would replay it to
+		// everyone, so index.html is never cached or revalidated.
+		ectx.Response().Header().Set("Cache-Control", "no-store")
+
+		// The scripts need the nonce of this request's CSP.
+		err := ectx.Blob(status, "text/html; charset=utf-8", wabmw.InjectNonce(files.index, wabmw.CSPNonce(ectx)))
+		if err != nil {
+			return fmt.Errorf("failed to load blob %q %w", ectx.Request().RequestURI, err)
+		}
+
+		return nil
+	}))
+}
+
+// uiFiles is a loaded copy of the UI, swapped as a whole when a UI directory
+// changes.
+type uiFiles struct {
+	static *static.Handler
+	index  []byte
+}
+
+func (s *WebServer) loadUI(uiConfig UIConfig) (*uiFiles, error) {
+	staticHandler, err := static.New(s.uiSource.FS)
+	if err != nil {
+		return nil, err
+	}
+
+	index, err := fs.ReadFile(s.uiSource.FS, "index.html")
+	if err != nil {
+		return nil, fmt.Errorf("loading index.html: %w", err)
+	}
+
+	index = uiConfig.inject(index)
+
+	return &uiFiles{
+		static: staticHandler,
+		index:  index,
+	}, nil
+}
+
+// csp is the configured Content-Security-Policy, letting the Vue app reach
+// the API when it lives on another origin.
+func (s *WebServer) csp() string {
+	apiBase, err := url.Parse(s.options.UIAPIBase)
+	if err != nil || apiBase.Host == "" {
+		return s.options.CSP
+	}
+
+	return wabmw.AllowConnect(s.options.CSP, apiBase.Scheme+"://"+apiBase.Host)
 }
 
 func (s *WebServer) getState() echo.HandlerFunc {
@@ -246,13 +560,25 @@ func (s *WebServer) getState() echo.HandlerFunc {
 	}
 }
 
+func (s *WebServer) getInfo() echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		result := map[string]interface{}{
+			"version": s.options.Version,
+			"ui":      s.uiSource,
+		}
+
+		return ctx.JSON(http.StatusOK, result)
+	}
+}
+
 func (s *WebServer) setupRoutes() {
-	s.e.GET("/api/v1/state", s.getState())
+	s.e.Match(getAndHead, "/api/v1/state", s.getState())
+	s.e.Match(getAndHead, "/api/v1/info", s.getInfo())
 }
 
 // Base Handlers
-func noEmbeddedUIHandler(ectx echo.Context) error {
-	return ectx.String(http.StatusOK, "No UI embedded in this copy of wab")
+func notFoundHandler(ectx echo.Context) error {
+	return echo.ErrNotFound
 }
 
 func noGRPCUIHandler(ectx echo.Context) error {